@@ -0,0 +1,41 @@
+package main
+
+import (
+	"crypto/subtle"
+	"log"
+	"net/http"
+	"os"
+
+	"instagram-scraper/session"
+)
+
+// sessionPool adalah pool sesi Instagram yang dipakai scraper (env SESSIONS_FILE,
+// atau satu sesi dari environment variable lama).
+var sessionPool *session.Pool
+
+// requireAdmin membungkus handler admin dan /debug/vars. Request harus membawa
+// header "Authorization: Bearer <ADMIN_TOKEN>". Tanpa ADMIN_TOKEN semua request
+// ditolak, jadi endpoint admin tidak pernah terbuka karena lupa konfigurasi.
+func requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := os.Getenv("ADMIN_TOKEN")
+		if token == "" {
+			log.Printf("Rejected admin request for %s from %s: ADMIN_TOKEN is not set", r.URL.Path, r.RemoteAddr)
+			writeError(w, http.StatusUnauthorized, "unauthorized", "Admin endpoints are disabled. Set ADMIN_TOKEN on the server to enable them.")
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+token)) != 1 {
+			log.Printf("Rejected admin request for %s from %s: missing or invalid token", r.URL.Path, r.RemoteAddr)
+			writeError(w, http.StatusUnauthorized, "unauthorized", "A valid 'Authorization: Bearer <ADMIN_TOKEN>' header is required.")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// getSessionsHandler menangani GET /admin/sessions: kesehatan setiap sesi di
+// pool (dipakai berapa kali, ditolak berapa kali, dikarantina sampai kapan).
+// Kredensial sesi tidak pernah dikirim.
+func getSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, sessionPool.Health())
+}
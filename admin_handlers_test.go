@@ -0,0 +1,40 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequireAdmin(t *testing.T) {
+	tests := []struct {
+		name   string
+		token  string // ADMIN_TOKEN
+		header string // Authorization
+		want   int
+	}{
+		{"tanpa ADMIN_TOKEN ditolak", "", "", http.StatusUnauthorized},
+		{"tanpa ADMIN_TOKEN, bearer kosong ditolak", "", "Bearer ", http.StatusUnauthorized},
+		{"tanpa header", "rahasia", "", http.StatusUnauthorized},
+		{"token salah", "rahasia", "Bearer salah", http.StatusUnauthorized},
+		{"tanpa prefix Bearer", "rahasia", "rahasia", http.StatusUnauthorized},
+		{"token benar", "rahasia", "Bearer rahasia", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("ADMIN_TOKEN", tt.token)
+			h := requireAdmin(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+			req := httptest.NewRequest(http.MethodGet, "/debug/vars", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			h(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
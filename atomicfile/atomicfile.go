@@ -0,0 +1,55 @@
+// Package atomicfile menulis file secara atomik: isi ditulis ke file sementara
+// di folder yang sama lalu di-rename ke nama tujuan, sehingga pembaca tidak
+// pernah melihat file yang setengah tertulis.
+package atomicfile
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Write memanggil write dengan file sementara, lalu me-rename-nya ke name jika
+// write dan sync berhasil. Jika gagal, file sementara dihapus dan file lama
+// (jika ada) tetap utuh.
+func Write(name string, write func(w io.Writer) error) error {
+	// os.CreateTemp membuat file dengan mode 0600; samakan dengan os.Create.
+	return WritePerm(name, 0644, write)
+}
+
+// WritePerm sama seperti Write, tetapi file akhirnya diberi mode perm (misalnya
+// 0600 untuk file yang berisi kredensial).
+func WritePerm(name string, perm os.FileMode, write func(w io.Writer) error) (err error) {
+	dir, base := filepath.Split(name)
+	if dir == "" {
+		dir = "."
+	}
+	tmp, err := os.CreateTemp(dir, "."+base+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("creating temp file for %s: %w", name, err)
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if err = write(tmp); err != nil {
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		return fmt.Errorf("syncing %s: %w", tmp.Name(), err)
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("closing %s: %w", tmp.Name(), err)
+	}
+	if err = os.Chmod(tmp.Name(), perm); err != nil {
+		return fmt.Errorf("chmod %s: %w", tmp.Name(), err)
+	}
+	if err = os.Rename(tmp.Name(), name); err != nil {
+		return fmt.Errorf("renaming %s to %s: %w", tmp.Name(), name, err)
+	}
+	return nil
+}
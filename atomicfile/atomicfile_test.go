@@ -0,0 +1,77 @@
+package atomicfile
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWritePerm(t *testing.T) {
+	errWrite := errors.New("gagal menulis")
+	tests := []struct {
+		name     string
+		existing string // Isi file lama; kosong jika belum ada
+		perm     os.FileMode
+		write    func(w io.Writer) error
+		want     string // Isi file setelahnya
+		wantErr  error
+	}{
+		{"file baru", "", 0644, writeString("baru"), "baru", nil},
+		{"menimpa file lama", "lama", 0644, writeString("baru"), "baru", nil},
+		{"mode 0600", "", 0600, writeString("rahasia"), "rahasia", nil},
+		{"gagal di tengah, file lama utuh", "lama", 0644, func(w io.Writer) error {
+			io.WriteString(w, "setengah")
+			return errWrite
+		}, "lama", errWrite},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			name := filepath.Join(dir, "out.json")
+			if tt.existing != "" {
+				if err := os.WriteFile(name, []byte(tt.existing), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := WritePerm(name, tt.perm, tt.write)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("WritePerm() error = %v, want %v", err, tt.wantErr)
+			}
+			data, _ := os.ReadFile(name)
+			if string(data) != tt.want {
+				t.Errorf("content = %q, want %q", data, tt.want)
+			}
+			if tt.wantErr == nil {
+				info, err := os.Stat(name)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if info.Mode().Perm() != tt.perm {
+					t.Errorf("mode = %o, want %o", info.Mode().Perm(), tt.perm)
+				}
+			}
+			// File sementara tidak pernah tertinggal.
+			entries, _ := os.ReadDir(dir)
+			if len(entries) > 1 || (len(entries) == 1 && entries[0].Name() != "out.json") {
+				t.Errorf("directory contains %v, want only out.json", entries)
+			}
+		})
+	}
+}
+
+func TestWriteMissingDirectory(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "tidak", "ada", "out.json")
+	if err := Write(name, writeString("x")); err == nil {
+		t.Error("Write() into a missing directory error = nil, want error")
+	}
+}
+
+func writeString(s string) func(w io.Writer) error {
+	return func(w io.Writer) error {
+		_, err := io.WriteString(w, s)
+		return err
+	}
+}
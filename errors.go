@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+
+	"instagram-scraper/posts"
+	"instagram-scraper/session"
+	"instagram-scraper/split"
+	"instagram-scraper/tagname"
+)
+
+// errorResponse adalah body JSON yang dikirim ke klien ketika request gagal.
+type errorResponse struct {
+	Error string `json:"error"`          // Pesan yang bisa dibaca manusia
+	Code  string `json:"code,omitempty"` // Kode singkat yang stabil untuk diproses mesin
+}
+
+// writeError mengirim respons error berformat JSON dengan status HTTP yang diberikan.
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(errorResponse{Error: message, Code: code}); err != nil {
+		log.Printf("Error writing error response: %v\n", err)
+	}
+}
+
+// writeScrapeError memetakan error dari posts.Posts / split.Split ke status HTTP yang sesuai.
+func writeScrapeError(w http.ResponseWriter, err error) {
+	status, code := scrapeErrorStatus(err)
+	// Teruskan Retry-After dari Instagram agar klien tahu kapan boleh mencoba lagi.
+	var statusErr *posts.StatusError
+	if errors.As(err, &statusErr) && statusErr.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(statusErr.RetryAfter.Seconds()))))
+	}
+	writeError(w, status, code, err.Error())
+}
+
+// scrapeErrorStatus mengembalikan status HTTP dan kode error untuk error scraping.
+func scrapeErrorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, split.ErrBadTimestamp):
+		return http.StatusBadRequest, "bad_timestamp"
+	case errors.Is(err, tagname.ErrInvalid):
+		return http.StatusBadRequest, "bad_hashtag"
+	case errors.Is(err, posts.ErrUnauthorized):
+		// Sesi Instagram milik server yang ditolak, bukan kredensial klien, jadi 502 bukan 401.
+		return http.StatusBadGateway, "unauthorized"
+	case errors.Is(err, session.ErrNoSession):
+		// Semua sesi Instagram sedang dikarantina; lihat GET /admin/sessions.
+		return http.StatusServiceUnavailable, "no_session"
+	case errors.Is(err, posts.ErrRateLimited):
+		return http.StatusTooManyRequests, "rate_limited"
+	case errors.Is(err, posts.ErrLoginWall):
+		// Sesi server logout: Instagram membalas halaman login alih-alih JSON.
+		return http.StatusBadGateway, "login_wall"
+	case errors.Is(err, posts.ErrLoginRequired):
+		return http.StatusBadGateway, "login_required"
+	case errors.Is(err, posts.ErrCheckpoint):
+		return http.StatusBadGateway, "checkpoint_required"
+	case errors.Is(err, split.ErrSchemaChanged):
+		return http.StatusBadGateway, "schema_changed"
+	case errors.Is(err, posts.ErrProxy):
+		// Proxy keluar server yang gagal, bukan Instagram.
+		return http.StatusBadGateway, "proxy_error"
+	case errors.Is(err, posts.ErrUpstream):
+		return http.StatusBadGateway, "upstream_error"
+	}
+	return http.StatusInternalServerError, "internal_error"
+}
@@ -0,0 +1,407 @@
+// Package fakeig adalah server Instagram palsu berbasis httptest untuk menguji
+// scraper tanpa jaringan. Server melayani endpoint web_info dan sections dengan
+// kursor paginasi, dari postingan buatan (AddPosts) atau respons web_info yang
+// direkam (AddRecording), dan bisa mensimulasikan 429, login wall, checkpoint
+// serta variasi skema respons.
+//
+// Contoh pengujian end-to-end /posts tanpa jaringan:
+//
+//	srv := fakeig.New()
+//	defer srv.Close()
+//	srv.AddPosts("kopi", split.FeedBoth, 6, fakeig.Post{Code: "A1", Username: "budi", Text: "#kopi", CreatedAt: time.Now().Unix()})
+//	posts.SetBaseURL(srv.URL)
+//	defer posts.SetBaseURL("")
+//	posts.SetSessionPool(session.Single(session.Session{Name: "test", Cookie: "sessionid=x"}))
+package fakeig
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/mux"
+
+	"instagram-scraper/split"
+	"instagram-scraper/tagname"
+)
+
+// Fault adalah kegagalan yang disimulasikan untuk satu request API.
+type Fault int
+
+const (
+	FaultRateLimited   Fault = iota + 1 // 429, dengan Retry-After jika SetRetryAfter dipanggil
+	FaultServerError                    // 500
+	FaultUnauthorized                   // 403, sesi ditolak
+	FaultLoginWall                      // 200 berisi halaman login HTML
+	FaultLoginRedirect                  // 302 ke /accounts/login/
+	FaultLoginRequired                  // 401 {"message": "login_required"}
+	FaultCheckpoint                     // 400 {"message": "checkpoint_required"}
+)
+
+// Schema adalah variasi bentuk respons yang dikirim server.
+type Schema int
+
+const (
+	SchemaGrid    Schema = iota // Section "media" dengan layout_content.medias (default)
+	SchemaClips                 // Section "clips" dengan layout_content.fill_items
+	SchemaUnknown               // data.top berupa array, sehingga hanya ekstraksi rekursif yang berhasil
+	SchemaNotJSON               // Body terpotong yang bukan JSON valid
+)
+
+// Post adalah satu postingan buatan untuk AddPosts.
+type Post struct {
+	Code         string
+	Username     string
+	Text         string
+	CreatedAt    int64 // Unix timestamp
+	Comments     int
+	Likes        int
+	Plays        int
+	IsVideo      bool
+	Duration     float64
+	ThumbnailURL string
+	VideoURL     string
+}
+
+// Request adalah satu request API yang diterima server, untuk diperiksa pengujian.
+type Request struct {
+	Method  string
+	Path    string
+	Hashtag string
+	Tab     string // Kosong untuk web_info
+	MaxID   string // Kursor yang dikirim ke endpoint sections
+	Cookie  string // Header cookie, untuk memeriksa sesi mana yang dipakai
+	Fault   Fault  // Kegagalan yang disimulasikan untuk request ini, 0 jika tidak ada
+}
+
+// Server adalah server Instagram palsu. Semua method aman dipanggil bersamaan
+// dengan request yang sedang dilayani.
+type Server struct {
+	*httptest.Server
+
+	mu         sync.Mutex
+	tags       map[string]*tag
+	faults     map[int]Fault // nomor request (mulai dari 1) -> kegagalan
+	schema     Schema
+	retryAfter int
+	requests   []Request
+}
+
+// tag menyimpan halaman-halaman feed satu hashtag.
+type tag struct {
+	info  map[string]interface{}     // Field data web_info selain feed (dari rekaman)
+	pages map[string][][]interface{} // tab -> halaman -> section mentah
+	fault Fault                      // Kegagalan untuk semua request hashtag ini (lihat FailHashtag)
+}
+
+// New menjalankan server palsu baru. Panggil Close setelah selesai.
+func New() *Server {
+	s := &Server{
+		tags:   make(map[string]*tag),
+		faults: make(map[int]Fault),
+	}
+	router := mux.NewRouter()
+	router.HandleFunc("/api/v1/tags/web_info/", s.webInfoHandler).Methods("GET")
+	router.HandleFunc("/api/v1/tags/{hashtag}/sections/", s.sectionsHandler).Methods("POST")
+	router.HandleFunc("/accounts/login/", loginPageHandler).Methods("GET")
+	s.Server = httptest.NewServer(router)
+	return s
+}
+
+// AddPosts mengganti isi feed hashtag (top, recent, atau keduanya) dengan posts,
+// perPage postingan per halaman (0 = semuanya dalam satu halaman).
+func (s *Server) AddPosts(hashtag string, feed split.Feed, perPage int, posts ...Post) {
+	if perPage <= 0 {
+		perPage = len(posts)
+	}
+	var pages [][]interface{}
+	for start := 0; start < len(posts); start += perPage {
+		end := start + perPage
+		if end > len(posts) {
+			end = len(posts)
+		}
+		pages = append(pages, gridSections(posts[start:end]))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := s.tag(hashtag)
+	for _, tab := range feed.Tabs() {
+		t.pages[tab] = pages
+	}
+}
+
+// AddRecording memakai respons web_info yang direkam sebagai isi hashtag, misalnya
+// posts_<hashtag>.json dari OUTPUT_DIR. Section setiap feed dibagi menjadi halaman
+// berisi perPage section (0 = satu halaman) dan dilayani dengan kursor buatan.
+// Field lain di "data" (name, media_count, dll.) ikut dikirim di web_info.
+func (s *Server) AddRecording(hashtag string, webInfo []byte, perPage int) error {
+	var recorded struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(webInfo, &recorded); err != nil {
+		return fmt.Errorf("decoding recording for %s: %w", hashtag, err)
+	}
+	if recorded.Data == nil {
+		return fmt.Errorf("recording for %s has no \"data\" object", hashtag)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := s.tag(hashtag)
+	for _, tab := range split.FeedBoth.Tabs() {
+		feed, _ := recorded.Data[tab].(map[string]interface{})
+		delete(recorded.Data, tab)
+		sections, _ := feed["sections"].([]interface{})
+		t.pages[tab] = paginate(sections, perPage)
+	}
+	t.info = recorded.Data
+	return nil
+}
+
+// Fail membuat n request API berikutnya gagal dengan fault.
+func (s *Server) Fail(fault Fault, n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := 1; i <= n; i++ {
+		s.faults[len(s.requests)+i] = fault
+	}
+}
+
+// FailRequest membuat request API ke-number (dihitung sejak server dibuat, mulai
+// dari 1) gagal dengan fault, misalnya untuk menggagalkan halaman kedua saja.
+func (s *Server) FailRequest(number int, fault Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults[number] = fault
+}
+
+// FailHashtag membuat semua request API untuk hashtag gagal dengan fault, tidak
+// peduli urutannya, misalnya untuk menggagalkan satu hashtag di antara beberapa
+// yang di-scrape bersamaan. Fault 0 menghapusnya lagi.
+func (s *Server) FailHashtag(hashtag string, fault Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tag(hashtag).fault = fault
+}
+
+// ClearFaults membatalkan semua kegagalan yang belum terjadi.
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = make(map[int]Fault)
+	for _, t := range s.tags {
+		t.fault = 0
+	}
+}
+
+// SetSchema mengganti bentuk respons untuk request berikutnya.
+func (s *Server) SetSchema(schema Schema) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.schema = schema
+}
+
+// SetRetryAfter mengatur header Retry-After (detik) pada respons FaultRateLimited. 0 = tanpa header.
+func (s *Server) SetRetryAfter(seconds int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.retryAfter = seconds
+}
+
+// Requests mengembalikan salinan semua request API yang sudah diterima, sesuai urutan.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// tag mengembalikan (dan membuat jika belum ada) isi hashtag. Harus dipanggil dengan s.mu terkunci.
+func (s *Server) tag(hashtag string) *tag {
+	if normalized, err := tagname.Normalize(hashtag); err == nil {
+		hashtag = normalized
+	}
+	t, ok := s.tags[hashtag]
+	if !ok {
+		t = &tag{pages: make(map[string][][]interface{})}
+		s.tags[hashtag] = t
+	}
+	return t
+}
+
+// record mencatat request dan mengembalikan kegagalan yang dijadwalkan untuknya.
+func (s *Server) record(req Request) (Fault, Schema, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	req.Fault = s.faults[len(s.requests)+1]
+	if t, ok := s.tags[req.Hashtag]; ok && req.Fault == 0 {
+		req.Fault = t.fault
+	}
+	s.requests = append(s.requests, req)
+	return req.Fault, s.schema, s.retryAfter
+}
+
+// page mengembalikan halaman ke-index feed tab dalam bentuk split.FeedSections mentah.
+func (s *Server) page(hashtag, tab string, index int) map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var pages [][]interface{}
+	if t, ok := s.tags[hashtag]; ok {
+		pages = t.pages[tab]
+	}
+	sections := []interface{}{}
+	if index < len(pages) {
+		sections = pages[index]
+	}
+	more := index+1 < len(pages)
+	nextMaxID := ""
+	if more {
+		nextMaxID = cursor(tab, index+1)
+	}
+	return map[string]interface{}{
+		"sections":       sections,
+		"more_available": more,
+		"next_max_id":    nextMaxID,
+		"next_page":      index + 1,
+		"next_media_ids": []string{},
+	}
+}
+
+// info mengembalikan salinan field data web_info milik hashtag.
+func (s *Server) info(hashtag string) map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data := map[string]interface{}{}
+	if t, ok := s.tags[hashtag]; ok {
+		for k, v := range t.info {
+			data[k] = v
+		}
+	}
+	return data
+}
+
+// webInfoHandler melayani GET /api/v1/tags/web_info/?tag_name=<hashtag>. Feed "top"
+// berisi halaman pertama, sedangkan "recent" dibiarkan kosong seperti yang sering
+// dilakukan Instagram, sehingga klien harus mengambilnya dari endpoint sections.
+func (s *Server) webInfoHandler(w http.ResponseWriter, r *http.Request) {
+	hashtag := r.URL.Query().Get("tag_name")
+	fault, schema, retryAfter := s.record(Request{Method: r.Method, Path: r.URL.Path, Hashtag: hashtag, Cookie: r.Header.Get("Cookie")})
+	if fault != 0 {
+		writeFault(w, r, fault, retryAfter)
+		return
+	}
+
+	data := s.info(hashtag)
+	data["name"] = hashtag
+	top := s.page(hashtag, "top", 0)
+	top["sections"] = reshape(top["sections"].([]interface{}), schema)
+	data["top"] = top
+	data["recent"] = map[string]interface{}{"sections": []interface{}{}, "more_available": false}
+	if schema == SchemaUnknown {
+		data["top"] = top["sections"]
+	}
+	writeJSON(w, map[string]interface{}{"count": 0, "data": data, "status": "ok"}, schema)
+}
+
+// sectionsHandler melayani POST /api/v1/tags/<hashtag>/sections/ dengan form
+// tab dan max_id (kosong = halaman pertama).
+func (s *Server) sectionsHandler(w http.ResponseWriter, r *http.Request) {
+	hashtag := mux.Vars(r)["hashtag"]
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	tab, maxID := r.PostForm.Get("tab"), r.PostForm.Get("max_id")
+	fault, schema, retryAfter := s.record(Request{Method: r.Method, Path: r.URL.Path, Hashtag: hashtag, Tab: tab, MaxID: maxID, Cookie: r.Header.Get("Cookie")})
+	if fault != 0 {
+		writeFault(w, r, fault, retryAfter)
+		return
+	}
+
+	index := 0
+	if maxID != "" {
+		var ok bool
+		if index, ok = parseCursor(tab, maxID); !ok {
+			log.Printf("fakeig: invalid max_id %q for tab '%s' of hashtag '%s'", maxID, tab, hashtag)
+			writeStatus(w, http.StatusBadRequest, map[string]interface{}{"message": "invalid max_id", "status": "fail"})
+			return
+		}
+	}
+	page := s.page(hashtag, tab, index)
+	page["sections"] = reshape(page["sections"].([]interface{}), schema)
+	page["status"] = "ok"
+	writeJSON(w, page, schema)
+}
+
+// loginPageHandler melayani halaman login tujuan FaultLoginRedirect.
+func loginPageHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprint(w, loginPage)
+}
+
+// loginPage meniru halaman login Instagram yang dikirim ke sesi yang sudah logout.
+const loginPage = `<!DOCTYPE html><html lang="en"><head><title>Login • Instagram</title></head>` +
+	`<body><form id="loginForm" method="post" action="/accounts/login/ajax/"></form></body></html>`
+
+// writeFault menulis respons untuk kegagalan yang disimulasikan.
+func writeFault(w http.ResponseWriter, r *http.Request, fault Fault, retryAfter int) {
+	switch fault {
+	case FaultRateLimited:
+		if retryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+		}
+		writeStatus(w, http.StatusTooManyRequests, map[string]interface{}{"message": "Please wait a few minutes before you try again.", "status": "fail"})
+	case FaultServerError:
+		writeStatus(w, http.StatusInternalServerError, map[string]interface{}{"message": "", "status": "fail"})
+	case FaultUnauthorized:
+		writeStatus(w, http.StatusForbidden, map[string]interface{}{"message": "", "status": "fail"})
+	case FaultLoginWall:
+		loginPageHandler(w, r)
+	case FaultLoginRedirect:
+		http.Redirect(w, r, "/accounts/login/?next="+r.URL.Path, http.StatusFound)
+	case FaultLoginRequired:
+		writeStatus(w, http.StatusUnauthorized, map[string]interface{}{"message": "login_required", "require_login": true, "status": "fail"})
+	case FaultCheckpoint:
+		writeStatus(w, http.StatusBadRequest, map[string]interface{}{"message": "checkpoint_required", "checkpoint_url": "/challenge/fakeig/", "lock": true, "status": "fail"})
+	default:
+		http.Error(w, fmt.Sprintf("unknown fault %d", fault), http.StatusInternalServerError)
+	}
+}
+
+// writeJSON menulis respons 200, atau body terpotong jika schema adalah SchemaNotJSON.
+func writeJSON(w http.ResponseWriter, v interface{}, schema Schema) {
+	if schema == SchemaNotJSON {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		fmt.Fprint(w, `{"data": {"top": {"sections": [`)
+		return
+	}
+	writeStatus(w, http.StatusOK, v)
+}
+
+func writeStatus(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("fakeig: error encoding response: %v", err)
+	}
+}
+
+// cursor membuat next_max_id untuk halaman ke-index feed tab.
+func cursor(tab string, index int) string {
+	return fmt.Sprintf("fakeig:%s:%d", tab, index)
+}
+
+// parseCursor membalik cursor. ok bernilai false untuk kursor yang bukan milik tab.
+func parseCursor(tab, maxID string) (int, bool) {
+	rest := strings.TrimPrefix(maxID, "fakeig:"+tab+":")
+	if rest == maxID {
+		return 0, false
+	}
+	index, err := strconv.Atoi(rest)
+	return index, err == nil && index > 0
+}
@@ -0,0 +1,106 @@
+package fakeig
+
+// mediasPerSection adalah jumlah media per section grid, sama seperti satu baris grid Instagram.
+const mediasPerSection = 3
+
+// media mengubah p menjadi objek media mentah seperti di respons Instagram.
+func (p Post) media() map[string]interface{} {
+	m := map[string]interface{}{
+		"code":           p.Code,
+		"comment_count":  p.Comments,
+		"like_count":     p.Likes,
+		"play_count":     p.Plays,
+		"is_video":       p.IsVideo,
+		"video_duration": p.Duration,
+		"caption": map[string]interface{}{
+			"created_at": p.CreatedAt,
+			"text":       p.Text,
+			"user":       map[string]interface{}{"username": p.Username},
+		},
+	}
+	if p.ThumbnailURL != "" {
+		m["image_versions2"] = map[string]interface{}{
+			"candidates": []interface{}{map[string]interface{}{"url": p.ThumbnailURL}},
+		}
+	}
+	if p.VideoURL != "" {
+		m["video_versions"] = []interface{}{map[string]interface{}{"url": p.VideoURL}}
+	}
+	return m
+}
+
+// gridSections menyusun posts menjadi section grid berisi paling banyak mediasPerSection media.
+func gridSections(posts []Post) []interface{} {
+	sections := []interface{}{}
+	for start := 0; start < len(posts); start += mediasPerSection {
+		end := start + mediasPerSection
+		if end > len(posts) {
+			end = len(posts)
+		}
+		medias := make([]interface{}, 0, end-start)
+		for _, p := range posts[start:end] {
+			medias = append(medias, map[string]interface{}{"media": p.media()})
+		}
+		sections = append(sections, map[string]interface{}{
+			"layout_type": "media",
+			"feed_type":   "media",
+			"explore_item_info": map[string]interface{}{
+				"num_columns":       mediasPerSection,
+				"total_num_columns": mediasPerSection,
+				"aspect_ratio":      1,
+				"autoplay":          false,
+			},
+			"layout_content": map[string]interface{}{"medias": medias},
+		})
+	}
+	return sections
+}
+
+// paginate membagi sections menjadi halaman berisi perPage section (0 = satu halaman).
+func paginate(sections []interface{}, perPage int) [][]interface{} {
+	if len(sections) == 0 {
+		return nil
+	}
+	if perPage <= 0 {
+		perPage = len(sections)
+	}
+	var pages [][]interface{}
+	for start := 0; start < len(sections); start += perPage {
+		end := start + perPage
+		if end > len(sections) {
+			end = len(sections)
+		}
+		pages = append(pages, sections[start:end])
+	}
+	return pages
+}
+
+// reshape menyesuaikan section dengan schema. Untuk SchemaClips, media di
+// layout_content.medias dipindah ke fill_items. Section asli tidak diubah.
+func reshape(sections []interface{}, schema Schema) []interface{} {
+	if schema != SchemaClips {
+		return sections
+	}
+	reshaped := make([]interface{}, 0, len(sections))
+	for _, raw := range sections {
+		section, ok := raw.(map[string]interface{})
+		if !ok {
+			reshaped = append(reshaped, raw)
+			continue
+		}
+		clips := make(map[string]interface{}, len(section))
+		for k, v := range section {
+			clips[k] = v
+		}
+		content, _ := section["layout_content"].(map[string]interface{})
+		items, _ := content["medias"].([]interface{})
+		if fill, ok := content["fill_items"].([]interface{}); ok {
+			items = append(append([]interface{}(nil), fill...), items...)
+		}
+		clips["layout_type"] = "clips"
+		clips["feed_type"] = "clips"
+		clips["layout_content"] = map[string]interface{}{"fill_items": items}
+		reshaped = append(reshaped, clips)
+	}
+	return reshaped
+}
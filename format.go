@@ -0,0 +1,210 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"instagram-scraper/split"
+)
+
+// outputFormat adalah salah satu format respons /posts.
+type outputFormat struct {
+	Name        string // Nilai query parameter 'format', juga ekstensi file
+	ContentType string
+	Attachment  bool                                                               // Diunduh sebagai file (CSV/XLSX) alih-alih ditampilkan di browser
+	write       func(w io.Writer, posts []split.Post, opts split.CSVOptions) error // Format selain CSV hanya memakai opts.Columns
+}
+
+var (
+	formatJSON    = outputFormat{"json", "application/json", false, writeJSONFormat}
+	formatCSV     = outputFormat{"csv", "text/csv; charset=utf-8", true, split.WriteCSVOptions}
+	formatNDJSON  = outputFormat{"ndjson", "application/x-ndjson", false, writeNDJSONFormat}
+	formatXLSX    = outputFormat{"xlsx", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", true, writeXLSXFormat}
+	formatParquet = outputFormat{"parquet", "application/vnd.apache.parquet", true, writeParquetFormat}
+)
+
+func writeJSONFormat(w io.Writer, posts []split.Post, opts split.CSVOptions) error {
+	return split.WriteJSONColumns(w, posts, opts.Columns)
+}
+
+func writeNDJSONFormat(w io.Writer, posts []split.Post, opts split.CSVOptions) error {
+	return split.WriteNDJSONColumns(w, posts, opts.Columns)
+}
+
+func writeXLSXFormat(w io.Writer, posts []split.Post, opts split.CSVOptions) error {
+	return split.WriteXLSXColumns(w, posts, opts)
+}
+
+// xlsxReportFormat adalah formatXLSX yang menulis laporan sheets (sheet ringkasan
+// dan satu sheet per hashtag) alih-alih postingan yang diberikan ke writePosts.
+func xlsxReportFormat(sheets []split.XLSXSheet) outputFormat {
+	report := formatXLSX
+	report.write = func(w io.Writer, _ []split.Post, opts split.CSVOptions) error {
+		return split.WriteXLSXReport(w, sheets, opts)
+	}
+	return report
+}
+
+// writeParquetFormat mengabaikan opts: skema Parquet selalu berisi semua kolom.
+func writeParquetFormat(w io.Writer, posts []split.Post, opts split.CSVOptions) error {
+	return split.WriteParquet(w, posts)
+}
+
+// formatNames adalah daftar nilai 'format' untuk pesan error, sesuai urutan di outputFormats.
+const formatNames = "json, csv, ndjson, xlsx, parquet"
+
+// outputFormats memetakan nilai query parameter 'format' (dan field "format" job) ke formatnya.
+var outputFormats = map[string]outputFormat{
+	"json":    formatJSON,
+	"csv":     formatCSV,
+	"ndjson":  formatNDJSON,
+	"xlsx":    formatXLSX,
+	"parquet": formatParquet,
+}
+
+// acceptTypes memetakan media type di header Accept ke format. Wildcard memilih JSON.
+var acceptTypes = map[string]outputFormat{
+	"application/json":     formatJSON,
+	"text/csv":             formatCSV,
+	"application/x-ndjson": formatNDJSON,
+	"application/ndjson":   formatNDJSON,
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet": formatXLSX,
+	"application/vnd.apache.parquet":                                    formatParquet,
+	"application/x-parquet":                                             formatParquet,
+	"application/*":                                                     formatJSON,
+	"*/*":                                                               formatJSON,
+}
+
+// errNotAcceptable dikembalikan negotiateFormat jika tidak ada format di Accept yang didukung.
+var errNotAcceptable = fmt.Errorf("none of the types in the Accept header are supported (use application/json, text/csv, application/x-ndjson, %s or %s)", formatXLSX.ContentType, formatParquet.ContentType)
+
+// negotiateFormat memilih format respons. Query parameter 'format' menang atas
+// header Accept; tanpa keduanya hasilnya JSON.
+func negotiateFormat(r *http.Request) (outputFormat, error) {
+	if name := r.URL.Query().Get("format"); name != "" {
+		f, ok := outputFormats[strings.ToLower(name)]
+		if !ok {
+			return outputFormat{}, fmt.Errorf("query parameter 'format' must be one of: %s", formatNames)
+		}
+		return f, nil
+	}
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return formatJSON, nil
+	}
+
+	// Urutkan media range menurut q (stabil, jadi urutan di header menjadi penentu jika q sama).
+	type mediaRange struct {
+		mediaType string
+		q         float64
+	}
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+		if q > 0 {
+			ranges = append(ranges, mediaRange{mediaType, q})
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+	for _, mr := range ranges {
+		if f, ok := acceptTypes[mr.mediaType]; ok {
+			return f, nil
+		}
+	}
+	return outputFormat{}, errNotAcceptable
+}
+
+// parseCSVOptions membaca kolom output dan dialek CSV dari query parameter:
+//
+//	columns=owner_username,likes,...    kolom output (atau "all"), untuk semua format
+//	csv_header=id|en                    bahasa judul kolom
+//	csv_header.<kolom>=Judul            judul kustom untuk satu kolom
+//	csv_delimiter=%3B|semicolon|tab|... satu karakter delimiter
+//	csv_quote=minimal|all
+//	csv_bom=true|false
+//	csv_newlines=keep|lf|crlf|space     baris baru di dalam caption (crlf juga untuk akhir baris)
+func parseCSVOptions(r *http.Request) (split.CSVOptions, error) {
+	query := r.URL.Query()
+	var opts split.CSVOptions
+	columns, err := split.ParseColumns(query.Get("columns"))
+	if err != nil {
+		return opts, fmt.Errorf("query parameter 'columns': %v", err)
+	}
+	opts.Columns = columns
+	opts.Language = query.Get("csv_header")
+	opts.Quoting = split.Quoting(query.Get("csv_quote"))
+	opts.Newlines = split.NewlineMode(query.Get("csv_newlines"))
+
+	for key, values := range query {
+		if col := strings.TrimPrefix(key, "csv_header."); col != key {
+			if opts.Headers == nil {
+				opts.Headers = make(map[split.Column]string)
+			}
+			opts.Headers[split.Column(col)] = values[0]
+		}
+	}
+
+	if opts.Comma, err = parseCSVDelimiter(query.Get("csv_delimiter")); err != nil {
+		return opts, fmt.Errorf("query parameter 'csv_delimiter' %v", err)
+	}
+
+	if v := query.Get("csv_bom"); v != "" {
+		bom, err := strconv.ParseBool(v)
+		if err != nil {
+			return opts, fmt.Errorf("query parameter 'csv_bom' must be true or false")
+		}
+		opts.BOM = bom
+	}
+	return opts, opts.Validate()
+}
+
+// parseCSVDelimiter mengubah nilai csv_delimiter menjadi rune. String kosong berarti
+// default (0). Nama alias ada karena ';' mentah di query string dibuang oleh net/url
+// (harus ditulis %3B).
+func parseCSVDelimiter(d string) (rune, error) {
+	switch d {
+	case "":
+		return 0, nil
+	case "tab", `\t`:
+		return '\t', nil
+	case "semicolon":
+		return ';', nil
+	case "comma":
+		return ',', nil
+	case "pipe":
+		return '|', nil
+	}
+	if utf8.RuneCountInString(d) != 1 {
+		return 0, fmt.Errorf("must be a single character, 'comma', 'semicolon', 'tab' or 'pipe'")
+	}
+	r, _ := utf8.DecodeRuneInString(d)
+	return r, nil
+}
+
+// writePosts mengirim posts dalam format f. name adalah nama file tanpa ekstensi
+// untuk header Content-Disposition.
+func writePosts(w http.ResponseWriter, f outputFormat, name string, posts []split.Post, opts split.CSVOptions) error {
+	disposition := "inline"
+	if f.Attachment {
+		disposition = "attachment"
+	}
+	w.Header().Set("Content-Type", f.ContentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": name + "." + f.Name}))
+	w.Header().Add("Vary", "Accept")
+	return f.write(w, posts, opts)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"instagram-scraper/split"
+)
+
+func TestNegotiateFormat(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		accept string
+		want   string // Nama format; kosong jika error
+		err    error  // errNotAcceptable, atau nil untuk error 'format' biasa
+	}{
+		{"default JSON", "", "", "json", nil},
+		{"query format", "format=csv", "", "csv", nil},
+		{"query tidak case-sensitive", "format=NDJSON", "", "ndjson", nil},
+		{"query menang atas Accept", "format=xlsx", "text/csv", "xlsx", nil},
+		{"query parquet", "format=parquet", "", "parquet", nil},
+		{"query tidak dikenal", "format=xml", "", "", nil},
+		{"Accept CSV", "", "text/csv", "csv", nil},
+		{"Accept dengan parameter", "", "text/csv; charset=utf-8", "csv", nil},
+		{"Accept NDJSON alias", "", "application/ndjson", "ndjson", nil},
+		{"Accept XLSX", "", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", "xlsx", nil},
+		{"Accept Parquet alias", "", "application/x-parquet", "parquet", nil},
+		{"wildcard", "", "*/*", "json", nil},
+		{"wildcard application", "", "application/*", "json", nil},
+		{"urutan header jika q sama", "", "text/csv, application/json", "csv", nil},
+		{"q tertinggi menang", "", "text/csv;q=0.5, application/x-ndjson", "ndjson", nil},
+		{"tipe tidak didukung dilewati", "", "text/html, text/csv;q=0.1", "csv", nil},
+		{"q=0 berarti tidak mau", "", "text/csv;q=0, application/json;q=0.2", "json", nil},
+		{"media range rusak dilewati", "", "bukan/tipe/valid, text/csv", "csv", nil},
+		{"browser", "", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", "json", nil},
+		{"tidak ada yang didukung", "", "text/html, image/png", "", errNotAcceptable},
+		{"hanya q=0", "", "text/csv;q=0", "", errNotAcceptable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/posts?"+tt.query, nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+			f, err := negotiateFormat(r)
+			if tt.want != "" {
+				if err != nil || f.Name != tt.want {
+					t.Errorf("negotiateFormat() = %q, %v; want %q", f.Name, err, tt.want)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("negotiateFormat() = %q, want error", f.Name)
+			}
+			if (tt.err == errNotAcceptable) != (err == errNotAcceptable) {
+				t.Errorf("negotiateFormat() error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestWritePostsXLSXReport(t *testing.T) {
+	rec := httptest.NewRecorder()
+	sheets := []split.XLSXSheet{{Name: "kopi"}}
+	if err := writePosts(rec, xlsxReportFormat(sheets), "job_ab12", nil, split.CSVOptions{}); err != nil {
+		t.Fatalf("writePosts() error = %v", err)
+	}
+	if got := rec.Header().Get("Content-Type"); got != formatXLSX.ContentType {
+		t.Errorf("Content-Type = %q, want %q", got, formatXLSX.ContentType)
+	}
+	if got := rec.Header().Get("Content-Disposition"); got != "attachment; filename=job_ab12.xlsx" {
+		t.Errorf("Content-Disposition = %q, want attachment; filename=job_ab12.xlsx", got)
+	}
+	if rec.Body.Len() == 0 {
+		t.Error("empty XLSX body")
+	}
+}
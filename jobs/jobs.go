@@ -0,0 +1,313 @@
+// Package jobs menjalankan scraping hashtag secara asinkron di worker pool
+// yang ukurannya dibatasi, sehingga scraping yang memakan waktu beberapa menit
+// tidak perlu menahan koneksi HTTP klien.
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"instagram-scraper/scraper"
+	"instagram-scraper/split"
+)
+
+var (
+	// ErrNotFound dikembalikan jika job dengan ID tersebut tidak ada (atau sudah dihapus karena kedaluwarsa).
+	ErrNotFound = errors.New("job not found")
+	// ErrQueueFull dikembalikan jika antrean job sudah penuh.
+	ErrQueueFull = errors.New("job queue is full")
+	// ErrNotFinished dikembalikan jika hasil diminta sebelum job selesai.
+	ErrNotFinished = errors.New("job has not finished yet")
+)
+
+// Status adalah tahap siklus hidup sebuah job.
+type Status string
+
+const (
+	StatusQueued    Status = "queued"
+	StatusRunning   Status = "running"
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+	StatusCanceled  Status = "canceled"
+)
+
+// Done melaporkan apakah status ini sudah final.
+func (s Status) Done() bool {
+	return s == StatusSucceeded || s == StatusFailed || s == StatusCanceled
+}
+
+// Spec adalah permintaan scraping yang sudah divalidasi.
+type Spec struct {
+	Hashtags []string
+	Options  scraper.Options  // Opsi yang sama dipakai untuk setiap hashtag
+	Format   string           // Format output hasil: json, csv, ndjson, xlsx atau parquet
+	Output   split.CSVOptions // Kolom output hasil (semua format) dan dialek CSV-nya
+}
+
+// RunFunc menjalankan scraping untuk satu hashtag. Biasanya scraper.Scrape.
+type RunFunc func(ctx context.Context, hashtag string, opts scraper.Options) ([]split.Post, error)
+
+// Progress menggambarkan kemajuan job yang sedang berjalan.
+type Progress struct {
+	HashtagsTotal  int    `json:"hashtags_total"`
+	HashtagsDone   int    `json:"hashtags_done"`
+	Posts          int    `json:"posts"`
+	CurrentHashtag string `json:"current_hashtag,omitempty"`
+}
+
+// Snapshot adalah salinan status job yang aman dikirim ke klien.
+type Snapshot struct {
+	ID            string            `json:"id"`
+	Status        Status            `json:"status"`
+	Hashtags      []string          `json:"hashtags"`
+	Format        string            `json:"format"`
+	Progress      Progress          `json:"progress"`
+	CreatedAt     time.Time         `json:"created_at"`
+	StartedAt     *time.Time        `json:"started_at,omitempty"`
+	FinishedAt    *time.Time        `json:"finished_at,omitempty"`
+	Error         string            `json:"error,omitempty"`
+	HashtagErrors map[string]string `json:"hashtag_errors,omitempty"`
+}
+
+// job adalah state internal satu job. Semua field dilindungi oleh Manager.mu.
+type job struct {
+	snap    Snapshot
+	spec    Spec
+	results map[string][]split.Post
+	ctx     context.Context
+	cancel  context.CancelFunc
+}
+
+// Manager menyimpan job di memori dan menjalankannya dengan sejumlah worker tetap.
+// Aman dipakai dari banyak goroutine (misalnya banyak klien yang polling bersamaan).
+type Manager struct {
+	run       RunFunc
+	queue     chan *job
+	retention time.Duration
+
+	mu   sync.Mutex
+	jobs map[string]*job
+}
+
+// NewManager membuat Manager dengan `workers` worker dan antrean berukuran queueSize.
+// Job yang sudah selesai dihapus dari memori setelah retention berlalu; penghapusan
+// dicek setiap kali Manager dipakai (Submit, Get, Result atau Cancel).
+func NewManager(workers, queueSize int, retention time.Duration, run RunFunc) *Manager {
+	if workers < 1 {
+		workers = 1
+	}
+	if queueSize < 1 {
+		queueSize = 1
+	}
+	m := &Manager{
+		run:       run,
+		queue:     make(chan *job, queueSize),
+		retention: retention,
+		jobs:      make(map[string]*job),
+	}
+	for i := 0; i < workers; i++ {
+		go m.worker()
+	}
+	return m
+}
+
+// Submit memasukkan job baru ke antrean dan langsung mengembalikan snapshot-nya.
+func (m *Manager) Submit(spec Spec) (Snapshot, error) {
+	id, err := newID()
+	if err != nil {
+		return Snapshot{}, err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	j := &job{
+		snap: Snapshot{
+			ID:        id,
+			Status:    StatusQueued,
+			Hashtags:  spec.Hashtags,
+			Format:    spec.Format,
+			Progress:  Progress{HashtagsTotal: len(spec.Hashtags)},
+			CreatedAt: time.Now().UTC(),
+		},
+		spec:    spec,
+		results: make(map[string][]split.Post),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+
+	m.mu.Lock()
+	m.pruneLocked()
+	select {
+	case m.queue <- j:
+		m.jobs[id] = j
+	default:
+		m.mu.Unlock()
+		cancel()
+		return Snapshot{}, ErrQueueFull
+	}
+	snap := j.snapshotLocked()
+	m.mu.Unlock()
+
+	log.Printf("Job %s queued for hashtags %v", id, spec.Hashtags)
+	return snap, nil
+}
+
+// Get mengembalikan snapshot job.
+func (m *Manager) Get(id string) (Snapshot, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pruneLocked()
+	j, ok := m.jobs[id]
+	if !ok {
+		return Snapshot{}, ErrNotFound
+	}
+	return j.snapshotLocked(), nil
+}
+
+// Result mengembalikan hasil per hashtag dari job yang sudah selesai, beserta spec-nya.
+// Hasil tidak dihapus setelah dibaca, jadi bisa diambil berkali-kali oleh banyak klien.
+func (m *Manager) Result(id string) (Snapshot, Spec, map[string][]split.Post, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pruneLocked()
+	j, ok := m.jobs[id]
+	if !ok {
+		return Snapshot{}, Spec{}, nil, ErrNotFound
+	}
+	if !j.snap.Status.Done() {
+		return j.snapshotLocked(), j.spec, nil, ErrNotFinished
+	}
+	results := make(map[string][]split.Post, len(j.results))
+	for tag, posts := range j.results {
+		results[tag] = posts
+	}
+	return j.snapshotLocked(), j.spec, results, nil
+}
+
+// Cancel membatalkan job. Job yang masih antre langsung ditandai canceled;
+// job yang sedang berjalan dihentikan melalui context-nya.
+func (m *Manager) Cancel(id string) (Snapshot, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pruneLocked()
+	j, ok := m.jobs[id]
+	if !ok {
+		return Snapshot{}, ErrNotFound
+	}
+	if !j.snap.Status.Done() {
+		j.cancel()
+		if j.snap.Status == StatusQueued {
+			j.finishLocked(StatusCanceled, "canceled before start")
+		}
+		log.Printf("Job %s cancellation requested", id)
+	}
+	return j.snapshotLocked(), nil
+}
+
+func (m *Manager) worker() {
+	for j := range m.queue {
+		m.execute(j)
+	}
+}
+
+// execute menjalankan semua hashtag di job secara berurutan.
+func (m *Manager) execute(j *job) {
+	m.mu.Lock()
+	if j.snap.Status != StatusQueued {
+		// Sudah dibatalkan ketika masih di antrean.
+		m.mu.Unlock()
+		return
+	}
+	now := time.Now().UTC()
+	j.snap.Status = StatusRunning
+	j.snap.StartedAt = &now
+	m.mu.Unlock()
+	log.Printf("Job %s started", j.snap.ID)
+
+	failed := 0
+	for _, hashtag := range j.spec.Hashtags {
+		m.mu.Lock()
+		j.snap.Progress.CurrentHashtag = hashtag
+		m.mu.Unlock()
+
+		posts, err := m.run(j.ctx, hashtag, j.spec.Options)
+
+		m.mu.Lock()
+		if err != nil {
+			failed++
+			if j.snap.HashtagErrors == nil {
+				j.snap.HashtagErrors = make(map[string]string)
+			}
+			j.snap.HashtagErrors[hashtag] = err.Error()
+			log.Printf("Job %s: hashtag '%s' failed: %v", j.snap.ID, hashtag, err)
+		} else {
+			j.results[hashtag] = posts
+			j.snap.Progress.Posts += len(posts)
+		}
+		j.snap.Progress.HashtagsDone++
+		m.mu.Unlock()
+
+		if j.ctx.Err() != nil {
+			break
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	j.snap.Progress.CurrentHashtag = ""
+	switch {
+	case j.ctx.Err() != nil:
+		j.finishLocked(StatusCanceled, "canceled")
+	case failed == len(j.spec.Hashtags):
+		j.finishLocked(StatusFailed, "all hashtags failed")
+	default:
+		j.finishLocked(StatusSucceeded, "")
+	}
+	j.cancel()
+	log.Printf("Job %s finished with status %s (%d posts)", j.snap.ID, j.snap.Status, j.snap.Progress.Posts)
+}
+
+func (j *job) finishLocked(status Status, errMsg string) {
+	now := time.Now().UTC()
+	j.snap.Status = status
+	j.snap.FinishedAt = &now
+	j.snap.Error = errMsg
+}
+
+// snapshotLocked menyalin snapshot beserta map/slice di dalamnya.
+func (j *job) snapshotLocked() Snapshot {
+	snap := j.snap
+	snap.Hashtags = append([]string(nil), j.snap.Hashtags...)
+	if j.snap.HashtagErrors != nil {
+		snap.HashtagErrors = make(map[string]string, len(j.snap.HashtagErrors))
+		for k, v := range j.snap.HashtagErrors {
+			snap.HashtagErrors[k] = v
+		}
+	}
+	return snap
+}
+
+// pruneLocked menghapus job yang sudah selesai lebih lama dari retention.
+func (m *Manager) pruneLocked() {
+	if m.retention <= 0 {
+		return
+	}
+	cutoff := time.Now().Add(-m.retention)
+	for id, j := range m.jobs {
+		if j.snap.Status.Done() && j.snap.FinishedAt != nil && j.snap.FinishedAt.Before(cutoff) {
+			delete(m.jobs, id)
+		}
+	}
+}
+
+func newID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating job id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
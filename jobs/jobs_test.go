@@ -0,0 +1,225 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"instagram-scraper/scraper"
+	"instagram-scraper/split"
+)
+
+// fakeRun mengembalikan satu postingan per hashtag, atau error untuk hashtag di failing.
+func fakeRun(failing ...string) RunFunc {
+	return func(ctx context.Context, hashtag string, opts scraper.Options) ([]split.Post, error) {
+		for _, f := range failing {
+			if f == hashtag {
+				return nil, errors.New("boom")
+			}
+		}
+		return []split.Post{{Code: hashtag}}, nil
+	}
+}
+
+// blockingRun menahan setiap panggilan sampai release ditutup atau ctx dibatalkan,
+// dan mencatat hashtag yang dijalankan di started.
+type blockingRun struct {
+	release chan struct{}
+	started chan string
+}
+
+func newBlockingRun() *blockingRun {
+	return &blockingRun{release: make(chan struct{}), started: make(chan string, 10)}
+}
+
+func (b *blockingRun) run(ctx context.Context, hashtag string, opts scraper.Options) ([]split.Post, error) {
+	b.started <- hashtag
+	select {
+	case <-b.release:
+		return []split.Post{{Code: hashtag}}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func TestManagerRunsJobs(t *testing.T) {
+	tests := []struct {
+		name       string
+		hashtags   []string
+		failing    []string
+		wantStatus Status
+		wantPosts  int
+		wantErrors int
+	}{
+		{"semua berhasil", []string{"kopi", "teh"}, nil, StatusSucceeded, 2, 0},
+		{"sebagian gagal", []string{"kopi", "teh"}, []string{"teh"}, StatusSucceeded, 1, 1},
+		{"semua gagal", []string{"kopi", "teh"}, []string{"kopi", "teh"}, StatusFailed, 0, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewManager(1, 10, time.Hour, fakeRun(tt.failing...))
+			snap, err := m.Submit(Spec{Hashtags: tt.hashtags, Format: "json"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			snap = waitDone(t, m, snap.ID)
+			if snap.Status != tt.wantStatus {
+				t.Errorf("status = %s, want %s", snap.Status, tt.wantStatus)
+			}
+			if snap.Progress.HashtagsDone != len(tt.hashtags) || snap.Progress.Posts != tt.wantPosts {
+				t.Errorf("progress = %+v, want %d hashtags done and %d posts", snap.Progress, len(tt.hashtags), tt.wantPosts)
+			}
+			if len(snap.HashtagErrors) != tt.wantErrors {
+				t.Errorf("hashtag errors = %v, want %d", snap.HashtagErrors, tt.wantErrors)
+			}
+			_, spec, results, err := m.Result(snap.ID)
+			if err != nil {
+				t.Fatalf("Result() error = %v", err)
+			}
+			if spec.Format != "json" || len(results) != len(tt.hashtags)-tt.wantErrors {
+				t.Errorf("Result() = %v, %v; want %d hashtags", spec, results, len(tt.hashtags)-tt.wantErrors)
+			}
+		})
+	}
+}
+
+func TestManagerCancelQueued(t *testing.T) {
+	b := newBlockingRun()
+	m := NewManager(1, 10, time.Hour, b.run)
+	running, _ := m.Submit(Spec{Hashtags: []string{"kopi"}})
+	<-b.started
+	queued, _ := m.Submit(Spec{Hashtags: []string{"teh"}})
+
+	snap, err := m.Cancel(queued.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Job yang masih antre langsung final tanpa menunggu worker.
+	if snap.Status != StatusCanceled || snap.StartedAt != nil || snap.FinishedAt == nil {
+		t.Errorf("Cancel(queued) = %+v, want canceled before start", snap)
+	}
+	if _, _, _, err := m.Result(queued.ID); err != nil {
+		t.Errorf("Result(canceled) error = %v, want nil", err)
+	}
+
+	close(b.release)
+	if snap := waitDone(t, m, running.ID); snap.Status != StatusSucceeded {
+		t.Errorf("running job status = %s, want succeeded", snap.Status)
+	}
+	// Worker melewati job yang sudah dibatalkan.
+	select {
+	case hashtag := <-b.started:
+		t.Errorf("canceled job ran hashtag %q", hashtag)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestManagerCancelRunning(t *testing.T) {
+	b := newBlockingRun()
+	m := NewManager(1, 10, time.Hour, b.run)
+	snap, _ := m.Submit(Spec{Hashtags: []string{"kopi", "teh"}})
+	<-b.started
+
+	if snap, err := m.Cancel(snap.ID); err != nil || snap.Status != StatusRunning {
+		t.Fatalf("Cancel(running) = %+v, %v; want still running until the worker stops", snap, err)
+	}
+	snap = waitDone(t, m, snap.ID)
+	if snap.Status != StatusCanceled || snap.StartedAt == nil {
+		t.Errorf("status = %s, want canceled after start", snap.Status)
+	}
+	// Hashtag kedua tidak dijalankan setelah pembatalan.
+	if snap.Progress.HashtagsDone != 1 {
+		t.Errorf("hashtags done = %d, want 1", snap.Progress.HashtagsDone)
+	}
+
+	// Membatalkan job yang sudah selesai tidak mengubah apa pun.
+	if again, err := m.Cancel(snap.ID); err != nil || again.Status != StatusCanceled || !again.FinishedAt.Equal(*snap.FinishedAt) {
+		t.Errorf("Cancel(finished) = %+v, %v; want unchanged", again, err)
+	}
+}
+
+func TestManagerErrors(t *testing.T) {
+	b := newBlockingRun()
+	defer close(b.release)
+	m := NewManager(1, 1, time.Hour, b.run)
+	running, _ := m.Submit(Spec{Hashtags: []string{"kopi"}})
+	<-b.started
+
+	if _, _, _, err := m.Result(running.ID); !errors.Is(err, ErrNotFinished) {
+		t.Errorf("Result(running) error = %v, want ErrNotFinished", err)
+	}
+	if _, err := m.Submit(Spec{Hashtags: []string{"teh"}}); err != nil {
+		t.Fatalf("Submit() filling the queue error = %v", err)
+	}
+	if _, err := m.Submit(Spec{Hashtags: []string{"susu"}}); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("Submit() on full queue error = %v, want ErrQueueFull", err)
+	}
+	if _, err := m.Get("tidakada"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get(unknown) error = %v, want ErrNotFound", err)
+	}
+	if _, err := m.Cancel("tidakada"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Cancel(unknown) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestManagerRetention(t *testing.T) {
+	m := NewManager(1, 10, 20*time.Millisecond, fakeRun())
+	old, _ := m.Submit(Spec{Hashtags: []string{"kopi"}})
+	waitDone(t, m, old.ID)
+
+	// Job yang baru selesai masih bisa dibaca.
+	recent, _ := m.Submit(Spec{Hashtags: []string{"teh"}})
+	if _, err := m.Get(old.ID); err != nil {
+		t.Errorf("Get() right after finishing error = %v", err)
+	}
+	waitDone(t, m, recent.ID)
+
+	time.Sleep(30 * time.Millisecond)
+	// Tanpa Submit baru pun, Get dan Result tidak lagi menemukan job lama.
+	if _, err := m.Get(old.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get(%s) after retention error = %v, want ErrNotFound", old.ID, err)
+	}
+	if _, _, _, err := m.Result(recent.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Result(%s) after retention error = %v, want ErrNotFound", recent.ID, err)
+	}
+}
+
+func TestManagerConcurrentPolling(t *testing.T) {
+	m := NewManager(2, 10, time.Hour, fakeRun())
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		snap, err := m.Submit(Spec{Hashtags: []string{"kopi", "teh"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			waitDone(t, m, id)
+		}(snap.ID)
+	}
+	wg.Wait()
+}
+
+// waitDone menunggu sampai job id selesai lalu mengembalikan snapshot-nya.
+func waitDone(t *testing.T, m *Manager, id string) Snapshot {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		snap, err := m.Get(id)
+		if err != nil {
+			t.Errorf("Get(%s) error = %v", id, err)
+			return snap
+		}
+		if snap.Status.Done() {
+			return snap
+		}
+		if time.Now().After(deadline) {
+			t.Errorf("job %s still %s after 2s", id, snap.Status)
+			return snap
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
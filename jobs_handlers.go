@@ -0,0 +1,218 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"instagram-scraper/jobs"
+	"instagram-scraper/posts"
+	"instagram-scraper/scraper"
+	"instagram-scraper/split"
+)
+
+// jobManager menjalankan job scraping asinkron. Diinisialisasi di main().
+var jobManager *jobs.Manager
+
+// createJobRequest adalah body JSON untuk POST /jobs.
+type createJobRequest struct {
+	Hashtags []string `json:"hashtags"`
+	Limit    *int64   `json:"limit,omitempty"`     // Unix timestamp. Default: 30 hari yang lalu
+	Feed     string   `json:"feed,omitempty"`      // top, recent, atau both. Default: top
+	MaxPages int      `json:"max_pages,omitempty"` // Default: posts.DefaultMaxPages
+	MaxPosts int      `json:"max_posts,omitempty"` // Default: tanpa batas
+	Format   string   `json:"format,omitempty"`    // Salah satu outputFormats (json, csv, ndjson, xlsx, parquet). Default: json
+	Columns  []string `json:"columns,omitempty"`   // Kolom output (lihat split.Column). Default: split.DefaultColumns
+
+	// Dialek CSV, sama dengan query parameter csv_* di /posts (lihat parseCSVOptions).
+	CSVHeader    string            `json:"csv_header,omitempty"`    // id atau en
+	CSVHeaders   map[string]string `json:"csv_headers,omitempty"`   // Judul kustom per kolom
+	CSVDelimiter string            `json:"csv_delimiter,omitempty"` // Satu karakter, atau comma/semicolon/tab/pipe
+	CSVQuote     string            `json:"csv_quote,omitempty"`     // minimal atau all
+	CSVBOM       bool              `json:"csv_bom,omitempty"`
+	CSVNewlines  string            `json:"csv_newlines,omitempty"` // keep, lf, crlf atau space
+}
+
+// csvOptions mengubah field kolom dan csv_* di body menjadi split.CSVOptions yang sudah divalidasi.
+func (req createJobRequest) csvOptions() (split.CSVOptions, error) {
+	var opts split.CSVOptions
+	columns, err := split.ParseColumns(strings.Join(req.Columns, ","))
+	if err != nil {
+		return opts, fmt.Errorf("Field 'columns': %v", err)
+	}
+	opts.Columns = columns
+	opts.Language = req.CSVHeader
+	opts.Quoting = split.Quoting(req.CSVQuote)
+	opts.Newlines = split.NewlineMode(req.CSVNewlines)
+	opts.BOM = req.CSVBOM
+	for col, header := range req.CSVHeaders {
+		if opts.Headers == nil {
+			opts.Headers = make(map[split.Column]string)
+		}
+		opts.Headers[split.Column(col)] = header
+	}
+	if opts.Comma, err = parseCSVDelimiter(req.CSVDelimiter); err != nil {
+		return opts, fmt.Errorf("Field 'csv_delimiter' %v", err)
+	}
+	return opts, opts.Validate()
+}
+
+// createJobHandler menangani POST /jobs: memvalidasi body lalu memasukkan job ke antrean.
+// Responsnya 202 Accepted dengan snapshot job dan header Location ke GET /jobs/{id}.
+func createJobHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("Received POST request for /jobs from %s", r.RemoteAddr)
+
+	var req createJobRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "bad_body", fmt.Sprintf("Request body must be valid JSON: %v", err))
+		return
+	}
+
+	spec := jobs.Spec{}
+	hashtags, err := parseHashtags(req.Hashtags)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_hashtag", fmt.Sprintf("Field 'hashtags': %v.", err))
+		return
+	}
+	spec.Hashtags = hashtags
+	if len(spec.Hashtags) == 0 {
+		writeError(w, http.StatusBadRequest, "missing_hashtag", "Field 'hashtags' must contain at least one hashtag.")
+		return
+	}
+
+	opts := posts.Options{MaxPages: req.MaxPages, MaxPosts: req.MaxPosts, Retry: retryPolicy}
+	if req.MaxPages < 0 || req.MaxPosts < 0 {
+		writeError(w, http.StatusBadRequest, "bad_max_pages", "Fields 'max_pages' and 'max_posts' must not be negative.")
+		return
+	}
+	if req.Limit != nil {
+		opts.Limit = *req.Limit
+	} else {
+		opts.Limit = defaultLimit().Unix()
+	}
+	feed, err := split.ParseFeed(req.Feed)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_feed", "Field 'feed' must be one of: top, recent, both.")
+		return
+	}
+	opts.Feed = feed
+	spec.Options = scraper.Options{Options: opts, Sink: scrapeSink()}
+
+	// Format yang sama dengan query parameter 'format' di /posts.
+	format := formatJSON
+	if req.Format != "" {
+		var ok bool
+		if format, ok = outputFormats[strings.ToLower(req.Format)]; !ok {
+			writeError(w, http.StatusBadRequest, "bad_format", fmt.Sprintf("Field 'format' must be one of: %s.", formatNames))
+			return
+		}
+	}
+	spec.Format = format.Name
+
+	if spec.Output, err = req.csvOptions(); err != nil {
+		writeError(w, http.StatusBadRequest, "bad_csv_options", err.Error())
+		return
+	}
+
+	snap, err := jobManager.Submit(spec)
+	if err != nil {
+		writeJobError(w, err)
+		return
+	}
+	w.Header().Set("Location", "/jobs/"+snap.ID)
+	writeJSON(w, http.StatusAccepted, snap)
+}
+
+// getJobHandler menangani GET /jobs/{id}: status dan progress job.
+func getJobHandler(w http.ResponseWriter, r *http.Request) {
+	snap, err := jobManager.Get(mux.Vars(r)["id"])
+	if err != nil {
+		writeJobError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, snap)
+}
+
+// jobResultResponse adalah body JSON untuk GET /jobs/{id}/result dengan format json.
+type jobResultResponse struct {
+	Job     jobs.Snapshot            `json:"job"`
+	Results map[string]split.Records `json:"results"`
+}
+
+// getJobResultHandler menangani GET /jobs/{id}/result. Job yang belum selesai menghasilkan 409.
+func getJobResultHandler(w http.ResponseWriter, r *http.Request) {
+	snap, spec, results, err := jobManager.Result(mux.Vars(r)["id"])
+	if err != nil {
+		writeJobError(w, err)
+		return
+	}
+
+	switch spec.Format {
+	case formatJSON.Name:
+		resp := jobResultResponse{Job: snap, Results: make(map[string]split.Records, len(results))}
+		for hashtag, posts := range results {
+			resp.Results[hashtag] = split.Records{Posts: split.Project(posts, spec.Output.Columns)}
+		}
+		writeJSON(w, http.StatusOK, resp)
+	case formatXLSX.Name:
+		// Satu sheet per hashtag, sesuai urutan di request, ditambah sheet ringkasan.
+		// Hashtag yang gagal tetap mendapat sheet (kosong) agar terlihat di ringkasan.
+		sheets := make([]split.XLSXSheet, len(spec.Hashtags))
+		for i, hashtag := range spec.Hashtags {
+			sheets[i] = split.XLSXSheet{Name: hashtag, Posts: results[hashtag]}
+		}
+		if err := writePosts(w, xlsxReportFormat(sheets), "job_"+snap.ID, nil, spec.Output); err != nil {
+			log.Printf("Error writing job result XLSX: %v\n", err)
+		}
+	default:
+		// CSV, NDJSON dan Parquet berisi postingan semua hashtag, berurutan sesuai
+		// urutan di request, ditulis dengan writer yang sama seperti /posts.
+		var all []split.Post
+		for _, hashtag := range spec.Hashtags {
+			all = append(all, results[hashtag]...)
+		}
+		if err := writePosts(w, outputFormats[spec.Format], "job_"+snap.ID, all, spec.Output); err != nil {
+			log.Printf("Error writing job result %s: %v\n", spec.Format, err)
+		}
+	}
+}
+
+// deleteJobHandler menangani DELETE /jobs/{id}: membatalkan job yang antre atau sedang berjalan.
+func deleteJobHandler(w http.ResponseWriter, r *http.Request) {
+	snap, err := jobManager.Cancel(mux.Vars(r)["id"])
+	if err != nil {
+		writeJobError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, snap)
+}
+
+// writeJobError memetakan error dari paket jobs ke status HTTP.
+func writeJobError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, jobs.ErrNotFound):
+		writeError(w, http.StatusNotFound, "job_not_found", err.Error())
+	case errors.Is(err, jobs.ErrNotFinished):
+		writeError(w, http.StatusConflict, "job_not_finished", err.Error())
+	case errors.Is(err, jobs.ErrQueueFull):
+		writeError(w, http.StatusServiceUnavailable, "queue_full", err.Error())
+	default:
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+	}
+}
+
+// writeJSON mengirim v sebagai JSON dengan status HTTP yang diberikan.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "    ")
+	if err := encoder.Encode(v); err != nil {
+		log.Printf("Error writing response data: %v\n", err)
+	}
+}
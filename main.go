@@ -1,112 +1,408 @@
-package main
-
-import (
-	"fmt"
-	"log"
-	"net/http"
-	"os"
-	"strconv" // Pastikan ini diimpor
-	"time"    // Pastikan ini diimpor
-
-	"github.com/gorilla/handlers"
-	"github.com/gorilla/mux"
-
-	"instagram-scraper/posts"
-	"instagram-scraper/split"
-)
-
-// getPostsHandler adalah handler HTTP untuk endpoint /posts.
-// Ia akan menerima request GET, memproses hashtag, melakukan scraping,
-// memfilter data, dan mengembalikan hasilnya dalam format JSON.
-func getPostsHandler(w http.ResponseWriter, r *http.Request) {
-	// Log setiap request yang masuk untuk keperluan debugging.
-	log.Printf("Received GET request for /posts from %s", r.RemoteAddr)
-
-	// Set header Content-Type untuk respons sebagai application/json.
-	w.Header().Set("Content-Type", "application/json")
-
-	// Ambil nilai hashtag dari query parameter URL (misalnya /posts?hashtag=KITTEN).
-	hashtag := r.URL.Query().Get("hashtag")
-	if hashtag == "" {
-		// Jika parameter hashtag tidak disediakan, kembalikan error Bad Request (400).
-		http.Error(w, `{"error": "Query parameter 'hashtag' is required."}`, http.StatusBadRequest)
-		log.Println("Error: 'hashtag' query parameter is missing.")
-		return // Hentikan eksekusi handler.
-	}
-	fmt.Printf("Processing hashtag: %s\n", hashtag)
-
-	// --- LOGIKA UNTUK FILTER TANGGAL DINAMIS ---
-	// Ambil nilai timestamp batas awal dari query parameter 'limit'.
-	// Jika tidak disediakan, hitung secara dinamis (misalnya, 30 hari yang lalu dari sekarang).
-	limitTimestampStr := r.URL.Query().Get("limit") // Coba ambil dari URL parameter
-
-	if limitTimestampStr == "" {
-		// Default: Hitung timestamp 30 hari yang lalu dari waktu sekarang (UTC)
-		// Anda bisa mengubah angka 30 di sini untuk periode yang berbeda (misal 7 hari, 90 hari)
-		defaultDaysAgo := 30
-		time30DaysAgo := time.Now().UTC().AddDate(0, 0, -defaultDaysAgo) // Menghitung tanggal N hari yang lalu
-		limitTimestampStr = strconv.FormatInt(time30DaysAgo.Unix(), 10) // Konversi ke string Unix timestamp
-		log.Printf("No 'limit' timestamp provided. Defaulting to %d days ago: %s (Unix: %s)", defaultDaysAgo, time30DaysAgo.Format("2006-01-02 15:04:05 UTC"), limitTimestampStr)
-	} else {
-		// Jika parameter 'limit' disediakan, log nilai yang digunakan.
-		parsedTime, err := strconv.ParseInt(limitTimestampStr, 10, 64)
-		if err == nil {
-			log.Printf("Using 'limit' timestamp from URL: %s (Parsed: %s)", limitTimestampStr, time.Unix(parsedTime, 0).Format("2006-01-02 15:04:05 UTC"))
-		} else {
-			log.Printf("Warning: Invalid 'limit' timestamp in URL: '%s'. Proceeding with provided string.", limitTimestampStr)
-		}
-	}
-
-	// --- Langkah 1: Melakukan Scraping Data dari Instagram ---
-	// Panggil fungsi posts.Posts yang akan mengambil data dari Instagram API.
-	// Fungsi ini akan menyimpan hasil mentah ke file bernama 'posts_NAMAHASHTAG.json'
-	posts.Posts(hashtag)
-
-	// Tentukan nama file input dan output untuk langkah pemrosesan data (split).
-	// inputFileName harus lengkap dengan path dan ekstensi.
-	inputFileName := fmt.Sprintf("/app/output/posts_%s.json", hashtag)
-	// outputBaseFileName adalah nama dasar tanpa ekstensi, karena split.Split akan membuat .json dan .csv
-	outputBaseFileName := fmt.Sprintf("/app/output/extracted_posts_%s", hashtag)
-
-	// --- Langkah 2: Memfilter dan Memisahkan Data ---
-	// Panggil fungsi split.Split untuk membaca file input, memfilter postingan
-	// berdasarkan timestamp batas, dan menulis hasilnya ke file output (JSON dan CSV).
-	split.Split(inputFileName, outputBaseFileName, limitTimestampStr)
-
-	// --- Langkah 3: Membaca Hasil Akhir untuk Dikembalikan via HTTP ---
-	// Baca file JSON yang sudah difilter dan dipisahkan oleh split.Split.
-	// Kita perlu tambahkan ekstensi .json secara manual di sini.
-	data, err := os.ReadFile(fmt.Sprintf("%s.json", outputBaseFileName))
-	if err != nil {
-		// Jika file output tidak dapat dibaca (mungkin karena tidak ada atau error saat pemrosesan),
-		// kembalikan error Internal Server Error (500).
-		log.Printf("Error reading final output JSON file '%s.json': %v\n", outputBaseFileName, err)
-		http.Error(w, fmt.Sprintf(`{"error": "Error reading extracted data file: %v"}`, err), http.StatusInternalServerError)
-		return
-	}
-
-	// --- Langkah 4: Mengirim Hasil ke Klien HTTP ---
-	// Tulis konten file JSON ke response writer HTTP.
-	if _, err := w.Write(data); err != nil {
-		log.Printf("Error writing response data: %v\n", err)
-	}
-	log.Printf("Successfully processed hashtag '%s' and sent response.", hashtag)
-}
-
-// main adalah fungsi entry point aplikasi server Go.
-func main() {
-	// Untuk logging, agar ada timestamp di setiap log
-	log.SetFlags(log.Ldate | log.Ltime | log.Lshortfile)
-
-	router := mux.NewRouter()
-	router.HandleFunc("/posts", getPostsHandler).Methods("GET")
-
-	corsHandler := handlers.CORS(
-		handlers.AllowedOrigins([]string{"*"}),                               // Izinkan semua origin. Hati-hati di production, batasi ke domain yang spesifik.
-		handlers.AllowedMethods([]string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}), // Metode HTTP yang diizinkan.
-		handlers.AllowedHeaders([]string{"Content-Type", "Authorization"}),   // Headers HTTP yang diizinkan.
-	)(router)
-
-	fmt.Println("Server started on :8000")
-	log.Fatal(http.ListenAndServe(":8000", corsHandler))
+package main
+
+import (
+	"expvar"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv" // Pastikan ini diimpor
+	"strings"
+	"time"    // Pastikan ini diimpor
+
+	"github.com/gorilla/handlers"
+	"github.com/gorilla/mux"
+
+	"instagram-scraper/jobs"
+	"instagram-scraper/posts"
+	"instagram-scraper/scraper"
+	"instagram-scraper/session"
+	"instagram-scraper/split"
+	"instagram-scraper/store"
+	"instagram-scraper/tagname"
+)
+
+// outputDir adalah folder tempat hasil scraping disimpan (env OUTPUT_DIR).
+// String kosong (default) berarti hasil tidak ditulis ke file sama sekali.
+var outputDir string
+
+// retryPolicy mengatur retry request ke Instagram (env RETRY_MAX_ATTEMPTS dan RETRY_MAX_ELAPSED).
+var retryPolicy posts.RetryPolicy
+
+// postStore menyimpan riwayat postingan di SQLite (env DB_PATH). Nil jika dinonaktifkan.
+var postStore *store.Store
+
+// scrapeSink mengembalikan sink untuk hasil scraping sesuai konfigurasi server:
+// file di OUTPUT_DIR dan/atau database di DB_PATH. Nil jika keduanya nonaktif.
+func scrapeSink() scraper.Sink {
+	var sinks scraper.MultiSink
+	if outputDir != "" {
+		sinks = append(sinks, scraper.FileSink{Dir: outputDir})
+	}
+	if postStore != nil {
+		sinks = append(sinks, postStore)
+	}
+	if len(sinks) == 0 {
+		return nil
+	}
+	return sinks
+}
+
+// defaultDaysAgo adalah periode filter tanggal default jika 'limit' tidak disediakan.
+// Anda bisa mengubah angka 30 di sini untuk periode yang berbeda (misal 7 hari, 90 hari)
+const defaultDaysAgo = 30
+
+// defaultLimit menghitung waktu defaultDaysAgo hari yang lalu dari sekarang (UTC).
+// Dibulatkan ke menit agar request default yang datang bersamaan memakai limit yang sama
+// dan bisa berbagi satu scraping yang sedang berjalan (lihat scraper.Scrape).
+func defaultLimit() time.Time {
+	return time.Now().UTC().AddDate(0, 0, -defaultDaysAgo).Truncate(time.Minute)
+}
+
+// getPostsHandler adalah handler HTTP untuk endpoint /posts.
+// Ia akan menerima request GET, memproses hashtag, melakukan scraping,
+// memfilter data, dan mengembalikan hasilnya dalam format JSON (atau CSV, NDJSON,
+// XLSX, Parquet sesuai query parameter 'format' atau header Accept).
+func getPostsHandler(w http.ResponseWriter, r *http.Request) {
+	// Log setiap request yang masuk untuk keperluan debugging.
+	log.Printf("Received GET request for /posts from %s", r.RemoteAddr)
+
+	// Ambil dan validasi hashtag, filter tanggal, dan opsi paginasi dari query parameter.
+	hashtags, scrapeOpts, ok := parseScrapeQuery(w, r)
+	if !ok {
+		return // Hentikan eksekusi handler. Error sudah dikirim ke klien.
+	}
+
+	// columns=owner_username,likes,... (atau "all") memilih kolom output, dan csv_* mengatur
+	// dialek CSV (lihat parseCSVOptions). Default: split.DefaultColumns dengan CSV standar.
+	csvOpts, err := parseCSVOptions(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_csv_options", err.Error())
+		return
+	}
+
+	// format=json|csv|ndjson|xlsx|parquet, atau header Accept jika 'format' tidak ada.
+	// Dicek sebelum scraping agar format yang tidak didukung tidak membuang request ke Instagram.
+	format, err := negotiateFormat(r)
+	if err != nil {
+		if err == errNotAcceptable {
+			writeError(w, http.StatusNotAcceptable, "not_acceptable", err.Error())
+		} else {
+			writeError(w, http.StatusBadRequest, "bad_format", err.Error())
+		}
+		return
+	}
+
+	// Beberapa hashtag (hashtag=a&hashtag=b atau hashtag=a,b) di-scrape bersamaan lalu digabung.
+	if len(hashtags) > 1 {
+		writeMultiPosts(w, r, hashtags, scrapeOpts, format, csvOpts)
+		return
+	}
+	hashtag := hashtags[0]
+
+	// NDJSON dikirim per postingan selama scraping berjalan, tanpa menunggu semua halaman.
+	if format.Name == formatNDJSON.Name {
+		streamNDJSON(w, r, hashtag, scrapeOpts, csvOpts.Columns)
+		return
+	}
+
+	// --- Langkah 1 & 2: Scraping dan Filter Data di Memori ---
+	// scraper.Scrape mengambil data dari Instagram API (mengikuti kursor paginasi sampai
+	// salah satu batas tercapai) lalu memfilter postingan berdasarkan timestamp batas.
+	// Jika gagal, hentikan di sini dan kirim error yang sesuai.
+	extracted, err := scraper.Scrape(r.Context(), hashtag, scrapeOpts)
+	if err != nil {
+		log.Printf("Error scraping hashtag '%s': %v", hashtag, err)
+		writeScrapeError(w, err)
+		return
+	}
+
+	// --- Langkah 3: Mengirim Hasil ke Klien HTTP ---
+	// Tulis hasil ekstraksi langsung dalam format yang diminta, tanpa membaca ulang file output.
+	if err := writePosts(w, format, "posts_"+hashtag, extracted, csvOpts); err != nil {
+		log.Printf("Error writing response data: %v\n", err)
+	}
+	log.Printf("Successfully processed hashtag '%s' and sent response.", hashtag)
+}
+
+// parseScrapeQuery membaca query parameter yang sama untuk semua endpoint scraping
+// (hashtag, limit, feed, max_pages, max_posts). Jika ada yang tidak valid, error
+// JSON langsung dikirim ke klien dan ok bernilai false. hashtags berisi minimal
+// satu hashtag tanpa duplikat.
+func parseScrapeQuery(w http.ResponseWriter, r *http.Request) (hashtags []string, scrapeOpts scraper.Options, ok bool) {
+	// Ambil nilai hashtag dari query parameter URL (misalnya /posts?hashtag=KITTEN).
+	// Beberapa hashtag boleh diulang (hashtag=a&hashtag=b) atau dipisah koma (hashtag=a,b).
+	// Setiap hashtag dinormalkan ('#' di depan dibuang, NFC, huruf kecil) sebelum dipakai.
+	hashtags, err := parseHashtags(r.URL.Query()["hashtag"])
+	if err != nil {
+		writeScrapeError(w, err)
+		log.Printf("Error: %v", err)
+		return nil, scraper.Options{}, false
+	}
+	if len(hashtags) == 0 {
+		// Jika parameter hashtag tidak disediakan, kembalikan error Bad Request (400).
+		writeError(w, http.StatusBadRequest, "missing_hashtag", "Query parameter 'hashtag' is required.")
+		log.Println("Error: 'hashtag' query parameter is missing.")
+		return nil, scraper.Options{}, false
+	}
+	if len(hashtags) > maxHashtagsPerRequest {
+		writeError(w, http.StatusBadRequest, "too_many_hashtags", fmt.Sprintf("At most %d hashtags can be scraped per request. Use POST /jobs for larger batches.", maxHashtagsPerRequest))
+		log.Printf("Error: %d hashtags requested, limit is %d.", len(hashtags), maxHashtagsPerRequest)
+		return nil, scraper.Options{}, false
+	}
+	fmt.Printf("Processing hashtags: %s\n", strings.Join(hashtags, ", "))
+
+	// --- LOGIKA UNTUK FILTER TANGGAL DINAMIS ---
+	// Ambil nilai timestamp batas awal dari query parameter 'limit'.
+	// Jika tidak disediakan, hitung secara dinamis (misalnya, 30 hari yang lalu dari sekarang).
+	limitTimestampStr := r.URL.Query().Get("limit") // Coba ambil dari URL parameter
+
+	if limitTimestampStr == "" {
+		// Default: Hitung timestamp 30 hari yang lalu dari waktu sekarang (UTC)
+		time30DaysAgo := defaultLimit()                                 // Menghitung tanggal N hari yang lalu
+		limitTimestampStr = strconv.FormatInt(time30DaysAgo.Unix(), 10) // Konversi ke string Unix timestamp
+		log.Printf("No 'limit' timestamp provided. Defaulting to %d days ago: %s (Unix: %s)", defaultDaysAgo, time30DaysAgo.Format("2006-01-02 15:04:05 UTC"), limitTimestampStr)
+	} else {
+		// Jika parameter 'limit' disediakan, validasi dulu sebelum menghubungi Instagram.
+		parsedTime, err := split.ParseLimit(limitTimestampStr)
+		if err != nil {
+			log.Printf("Error: Invalid 'limit' timestamp in URL: '%s'.", limitTimestampStr)
+			writeScrapeError(w, err)
+			return nil, scraper.Options{}, false
+		}
+		log.Printf("Using 'limit' timestamp from URL: %s (Parsed: %s)", limitTimestampStr, time.Unix(parsedTime, 0).Format("2006-01-02 15:04:05 UTC"))
+	}
+
+	// --- Opsi paginasi ---
+	// max_pages dan max_posts membatasi berapa jauh kursor paginasi Instagram diikuti.
+	// Paginasi juga berhenti sendiri begitu semua postingan di satu halaman lebih lama dari 'limit'.
+	opts := posts.Options{Retry: retryPolicy}
+	opts.Limit, _ = strconv.ParseInt(limitTimestampStr, 10, 64)
+	// feed=top|recent|both memilih feed hashtag yang diambil (default: top).
+	feed, err := split.ParseFeed(r.URL.Query().Get("feed"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_feed", "Query parameter 'feed' must be one of: top, recent, both.")
+		log.Printf("Error: %v", err)
+		return nil, scraper.Options{}, false
+	}
+	opts.Feed = feed
+	if v := r.URL.Query().Get("max_pages"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			writeError(w, http.StatusBadRequest, "bad_max_pages", "Query parameter 'max_pages' must be a positive integer.")
+			log.Printf("Error: invalid 'max_pages' value '%s'.", v)
+			return nil, scraper.Options{}, false
+		}
+		opts.MaxPages = n
+	}
+	if v := r.URL.Query().Get("max_posts"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, "bad_max_posts", "Query parameter 'max_posts' must be a non-negative integer.")
+			log.Printf("Error: invalid 'max_posts' value '%s'.", v)
+			return nil, scraper.Options{}, false
+		}
+		opts.MaxPosts = n
+	}
+
+	scrapeOpts = scraper.Options{Options: opts, Sink: scrapeSink()}
+	return hashtags, scrapeOpts, true
+}
+
+// maxHashtagsPerRequest membatasi jumlah hashtag dalam satu request /posts.
+const maxHashtagsPerRequest = 30
+
+// parseHashtags memecah nilai query parameter 'hashtag' yang dipisah koma,
+// membuang nilai kosong, menormalkan setiap hashtag dengan tagname.Normalize,
+// serta menghapus duplikat (urutan dipertahankan). Hashtag yang tidak valid
+// menghasilkan error yang membungkus tagname.ErrInvalid.
+func parseHashtags(values []string) ([]string, error) {
+	var hashtags []string
+	seen := make(map[string]bool)
+	for _, value := range values {
+		for _, hashtag := range strings.Split(value, ",") {
+			if strings.TrimSpace(hashtag) == "" {
+				continue
+			}
+			hashtag, err := tagname.Normalize(hashtag)
+			if err != nil {
+				return nil, err
+			}
+			if seen[hashtag] {
+				continue
+			}
+			seen[hashtag] = true
+			hashtags = append(hashtags, hashtag)
+		}
+	}
+	return hashtags, nil
+}
+
+// envInt membaca environment variable sebagai integer, atau mengembalikan def jika kosong/tidak valid.
+func envInt(name string, def int) int {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		log.Printf("WARNING: %s=%q is not a valid integer. Using default %d.", name, v, def)
+		return def
+	}
+	return n
+}
+
+// envDuration membaca environment variable sebagai durasi Go (misalnya "90s" atau "2m"),
+// atau mengembalikan def jika kosong/tidak valid.
+func envDuration(name string, def time.Duration) time.Duration {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		log.Printf("WARNING: %s=%q is not a valid duration. Using default %s.", name, v, def)
+		return def
+	}
+	return d
+}
+
+// main adalah fungsi entry point aplikasi server Go.
+func main() {
+	// Untuk logging, agar ada timestamp di setiap log
+	log.SetFlags(log.Ldate | log.Ltime | log.Lshortfile)
+
+	// OUTPUT_DIR mengaktifkan penyimpanan hasil ke file. Image Docker mengisinya
+	// dengan /app/output; tanpa OUTPUT_DIR hasil hanya dikembalikan lewat API.
+	outputDir = os.Getenv("OUTPUT_DIR")
+	if outputDir != "" {
+		if err := os.MkdirAll(outputDir, 0755); err != nil {
+			log.Printf("WARNING: Could not create OUTPUT_DIR '%s': %v", outputDir, err)
+		}
+		log.Printf("Saving scrape output files to '%s'", outputDir)
+	} else {
+		log.Println("OUTPUT_DIR is empty. Scrape output will not be saved to files.")
+	}
+
+	// DB_PATH menentukan lokasi database SQLite untuk riwayat postingan. Default-nya
+	// <OUTPUT_DIR>/posts.db jika OUTPUT_DIR di-set, selain itu database nonaktif;
+	// set ke string kosong (DB_PATH=) untuk menonaktifkannya. Jika database gagal
+	// dibuka, server tetap berjalan tanpa database.
+	dbPath := ""
+	if outputDir != "" {
+		dbPath = filepath.Join(outputDir, "posts.db")
+	}
+	if path, ok := os.LookupEnv("DB_PATH"); ok {
+		dbPath = path
+	}
+	if dbPath != "" {
+		s, err := store.Open(dbPath)
+		if err != nil {
+			log.Printf("ERROR: Could not open post store, continuing without it: %v", err)
+		} else {
+			defer s.Close()
+			postStore = s
+		}
+	} else {
+		log.Println("DB_PATH is empty. Scraped posts will not be stored in a database.")
+	}
+
+	// Worker pool untuk job asinkron. JOB_WORKERS membatasi jumlah scraping yang
+	// berjalan bersamaan, JOB_QUEUE_SIZE membatasi jumlah job yang boleh antre.
+	// RETRY_MAX_ATTEMPTS (termasuk percobaan pertama; 1 = tanpa retry) dan RETRY_MAX_ELAPSED
+	// membatasi pengulangan request ke Instagram yang gagal karena 429, 5xx, atau error jaringan.
+	retryPolicy = posts.RetryPolicy{
+		MaxAttempts: envInt("RETRY_MAX_ATTEMPTS", posts.DefaultRetryPolicy.MaxAttempts),
+		MaxElapsed:  envDuration("RETRY_MAX_ELAPSED", posts.DefaultRetryPolicy.MaxElapsed),
+	}
+
+	// Rate limiter untuk semua request ke Instagram: RATE_LIMIT_RPM/RATE_LIMIT_BURST untuk
+	// seluruh proses, SESSION_RATE_LIMIT_RPM/SESSION_RATE_LIMIT_BURST per sesi. RPM 0 = tanpa batas.
+	// Request yang melebihi batas menunggu giliran, bukan gagal.
+	posts.SetRateLimit(
+		posts.RateLimit{
+			PerMinute: float64(envInt("RATE_LIMIT_RPM", int(posts.DefaultRateLimit.PerMinute))),
+			Burst:     envInt("RATE_LIMIT_BURST", posts.DefaultRateLimit.Burst),
+		},
+		posts.RateLimit{
+			PerMinute: float64(envInt("SESSION_RATE_LIMIT_RPM", int(posts.DefaultSessionRateLimit.PerMinute))),
+			Burst:     envInt("SESSION_RATE_LIMIT_BURST", posts.DefaultSessionRateLimit.Burst),
+		},
+	)
+
+	// SESSIONS_FILE menunjuk file JSON berisi pool sesi Instagram (lihat session.Config).
+	// Tanpa file, dipakai satu sesi dari COOKIE, X_CSRFTOKEN, dll.
+	if path := os.Getenv("SESSIONS_FILE"); path != "" {
+		pool, err := session.Load(path)
+		if err != nil {
+			log.Fatalf("Error loading Instagram sessions: %v", err)
+		}
+		sessionPool = pool
+		log.Printf("Loaded %d Instagram sessions from '%s'", pool.Len(), path)
+	} else {
+		sessionPool = session.Single(session.FromEnv())
+	}
+	// SESSION_STATE_FILE menyimpan cookie, csrftoken dan www-claim yang diperbarui Instagram
+	// agar tetap dipakai setelah restart. Default-nya <OUTPUT_DIR>/session_state.json;
+	// set ke string kosong untuk menonaktifkannya.
+	statePath := ""
+	if outputDir != "" {
+		statePath = filepath.Join(outputDir, "session_state.json")
+	}
+	if path, ok := os.LookupEnv("SESSION_STATE_FILE"); ok {
+		statePath = path
+	}
+	if statePath != "" {
+		if err := sessionPool.SetStateFile(statePath); err != nil {
+			log.Fatalf("Error loading Instagram session state: %v", err)
+		}
+	}
+	posts.SetSessionPool(sessionPool)
+
+	// PROXY_URL (http://, https:// atau socks5://) dipakai untuk sesi yang tidak punya
+	// "proxy" sendiri di SESSIONS_FILE.
+	if err := posts.SetDefaultProxy(os.Getenv("PROXY_URL")); err != nil {
+		log.Fatalf("Error in PROXY_URL: %v", err)
+	}
+
+	// INSTAGRAM_BASE_URL mengarahkan semua request ke server lain, misalnya server fakeig
+	// untuk pengujian tanpa jaringan. Kosong = https://www.instagram.com.
+	if err := posts.SetBaseURL(os.Getenv("INSTAGRAM_BASE_URL")); err != nil {
+		log.Fatalf("Error in INSTAGRAM_BASE_URL: %v", err)
+	}
+	if base := posts.BaseURL(); base != posts.DefaultBaseURL {
+		log.Printf("Using Instagram base URL %s.", base)
+	}
+
+	// SCRAPE_CONCURRENCY membatasi jumlah hashtag yang di-scrape bersamaan dalam satu request /posts.
+	scrapeConcurrency = envInt("SCRAPE_CONCURRENCY", scraper.DefaultConcurrency)
+
+	jobManager = jobs.NewManager(envInt("JOB_WORKERS", 2), envInt("JOB_QUEUE_SIZE", 100), time.Hour, scraper.Scrape)
+
+	if os.Getenv("ADMIN_TOKEN") == "" {
+		log.Println("ADMIN_TOKEN is not set; /admin/sessions and /debug/vars will reject every request.")
+	}
+
+	router := mux.NewRouter()
+	router.HandleFunc("/posts", getPostsHandler).Methods("GET")
+	router.HandleFunc("/posts/stream", streamPostsHandler).Methods("GET")
+	router.HandleFunc("/jobs", createJobHandler).Methods("POST")
+	router.HandleFunc("/jobs/{id}", getJobHandler).Methods("GET")
+	router.HandleFunc("/jobs/{id}/result", getJobResultHandler).Methods("GET")
+	router.HandleFunc("/jobs/{id}", deleteJobHandler).Methods("DELETE")
+	router.HandleFunc("/stored/posts", getStoredPostsHandler).Methods("GET")
+	router.HandleFunc("/stored/posts/{code}/history", getPostHistoryHandler).Methods("GET")
+	router.HandleFunc("/stored/hashtags/{hashtag}/velocity", getHashtagVelocityHandler).Methods("GET")
+	router.HandleFunc("/debug/vars", requireAdmin(expvar.Handler().ServeHTTP)).Methods("GET") // Metrik proses (request, retry ke Instagram, ...)
+	router.HandleFunc("/admin/sessions", requireAdmin(getSessionsHandler)).Methods("GET")
+
+	corsHandler := handlers.CORS(
+		handlers.AllowedOrigins([]string{"*"}),                               // Izinkan semua origin. Hati-hati di production, batasi ke domain yang spesifik.
+		handlers.AllowedMethods([]string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}), // Metode HTTP yang diizinkan.
+		handlers.AllowedHeaders([]string{"Content-Type", "Authorization"}),   // Headers HTTP yang diizinkan.
+	)(router)
+
+	fmt.Println("Server started on :8000")
+	log.Fatal(http.ListenAndServe(":8000", corsHandler))
 }
\ No newline at end of file
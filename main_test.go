@@ -0,0 +1,272 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"instagram-scraper/fakeig"
+	"instagram-scraper/posts"
+	"instagram-scraper/session"
+	"instagram-scraper/split"
+)
+
+// newFakeInstagram menjalankan fakeig dan mengarahkan scraper ke sana dengan
+// retry cepat, tanpa rate limit, tanpa file output, dan satu sesi palsu.
+func newFakeInstagram(t *testing.T) *fakeig.Server {
+	t.Helper()
+	srv := fakeig.New()
+	t.Cleanup(srv.Close)
+	if err := posts.SetBaseURL(srv.URL); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { posts.SetBaseURL("") })
+
+	posts.SetRateLimit(posts.RateLimit{}, posts.RateLimit{})
+	t.Cleanup(func() { posts.SetRateLimit(posts.DefaultRateLimit, posts.DefaultSessionRateLimit) })
+	oldPool := posts.SetSessionPool(session.Single(session.Session{Name: "test", Cookie: "sessionid=x"}))
+	t.Cleanup(func() { posts.SetSessionPool(oldPool) })
+
+	oldRetry, oldOutput := retryPolicy, outputDir
+	retryPolicy = posts.RetryPolicy{MaxAttempts: 3, MaxElapsed: time.Second, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+	outputDir = ""
+	t.Cleanup(func() { retryPolicy, outputDir = oldRetry, oldOutput })
+	return srv
+}
+
+// fakePosts membuat n postingan baru (di dalam limit default) dengan kode P1..Pn.
+func fakePosts(n int) []fakeig.Post {
+	now := time.Now().Unix()
+	out := make([]fakeig.Post, n)
+	for i := range out {
+		out[i] = fakeig.Post{
+			Code:      fmt.Sprintf("P%d", i+1),
+			Username:  "budi",
+			Text:      "#kopi",
+			CreatedAt: now - int64(i*60),
+			Comments:  i,
+		}
+	}
+	return out
+}
+
+// getPosts memanggil getPostsHandler untuk query.
+func getPosts(query string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/posts?"+query, nil)
+	rec := httptest.NewRecorder()
+	getPostsHandler(rec, req)
+	return rec
+}
+
+func TestGetPostsFollowsPagination(t *testing.T) {
+	srv := newFakeInstagram(t)
+	srv.AddPosts("kopi", split.FeedBoth, 4, fakePosts(10)...)
+
+	rec := getPosts("hashtag=kopi&feed=both")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200; body: %s", rec.Code, rec.Body)
+	}
+	if got := decodePosts(t, rec); len(got) != 10 {
+		t.Errorf("got %d posts, want 10 (top and recent share the same codes)", len(got))
+	}
+
+	// Setiap feed punya tiga halaman; halaman kedua dan ketiga diminta dengan kursor.
+	cursors := 0
+	for _, req := range srv.Requests() {
+		if req.MaxID != "" {
+			cursors++
+		}
+	}
+	if cursors != 4 {
+		t.Errorf("%d requests carried a cursor, want 4; requests: %+v", cursors, srv.Requests())
+	}
+}
+
+func TestGetPostsMaxPostsCapsResult(t *testing.T) {
+	srv := newFakeInstagram(t)
+	srv.AddPosts("kopi", split.FeedBoth, 4, fakePosts(10)...)
+
+	for _, feed := range []string{"top", "both"} {
+		rec := getPosts("hashtag=kopi&max_posts=2&feed=" + feed)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("feed=%s: status = %d, want 200; body: %s", feed, rec.Code, rec.Body)
+		}
+		got := decodePosts(t, rec)
+		if len(got) != 2 {
+			t.Errorf("feed=%s: got %d posts, want 2", feed, len(got))
+		}
+	}
+}
+
+func TestGetPostsRateLimited(t *testing.T) {
+	srv := newFakeInstagram(t)
+	srv.AddPosts("kopi", split.FeedTop, 0, fakePosts(2)...)
+	srv.Fail(fakeig.FaultRateLimited, 10)
+	srv.SetRetryAfter(30)
+
+	rec := getPosts("hashtag=kopi")
+	assertError(t, rec, http.StatusTooManyRequests, "rate_limited")
+	if got := rec.Header().Get("Retry-After"); got != "30" {
+		t.Errorf("Retry-After = %q, want 30", got)
+	}
+	// Retry-After 30 detik melewati MaxElapsed, jadi tidak ada retry.
+	if n := len(srv.Requests()); n != 1 {
+		t.Errorf("Instagram got %d requests, want 1", n)
+	}
+}
+
+func TestGetPostsUpstreamErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		fault  fakeig.Fault
+		status int
+		code   string
+	}{
+		{"halaman login", fakeig.FaultLoginWall, http.StatusBadGateway, "login_wall"},
+		{"redirect ke login", fakeig.FaultLoginRedirect, http.StatusBadGateway, "login_wall"},
+		{"login_required", fakeig.FaultLoginRequired, http.StatusBadGateway, "login_required"},
+		{"checkpoint_required", fakeig.FaultCheckpoint, http.StatusBadGateway, "checkpoint_required"},
+		{"403", fakeig.FaultUnauthorized, http.StatusBadGateway, "unauthorized"},
+		{"500 terus-menerus", fakeig.FaultServerError, http.StatusBadGateway, "upstream_error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newFakeInstagram(t)
+			srv.AddPosts("kopi", split.FeedTop, 0, fakePosts(2)...)
+			srv.Fail(tt.fault, 10)
+
+			assertError(t, getPosts("hashtag=kopi"), tt.status, tt.code)
+		})
+	}
+}
+
+func TestGetPostsDeadProxy(t *testing.T) {
+	srv := newFakeInstagram(t)
+	srv.AddPosts("kopi", split.FeedTop, 0, fakePosts(2)...)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	if err := posts.SetDefaultProxy("http://" + addr); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { posts.SetDefaultProxy("") })
+
+	assertError(t, getPosts("hashtag=kopi"), http.StatusBadGateway, "proxy_error")
+	if n := len(srv.Requests()); n != 0 {
+		t.Errorf("Instagram got %d requests, want none through a dead proxy", n)
+	}
+}
+
+func TestGetPostsSchemaVariants(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema fakeig.Schema
+		status int
+		code   string // Kosong jika berhasil
+		posts  int
+	}{
+		{"grid", fakeig.SchemaGrid, http.StatusOK, "", 10},
+		{"clips", fakeig.SchemaClips, http.StatusOK, "", 10},
+		// Hanya ekstraksi rekursif yang berhasil, dan tanpa kursor yang dikenali hanya halaman pertama yang diambil.
+		{"unknown", fakeig.SchemaUnknown, http.StatusOK, "", 4},
+		{"not json", fakeig.SchemaNotJSON, http.StatusBadGateway, "schema_changed", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newFakeInstagram(t)
+			srv.AddPosts("kopi", split.FeedTop, 4, fakePosts(10)...)
+			srv.SetSchema(tt.schema)
+
+			rec := getPosts("hashtag=kopi")
+			if tt.code != "" {
+				assertError(t, rec, tt.status, tt.code)
+				return
+			}
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d; body: %s", rec.Code, tt.status, rec.Body)
+			}
+			if got := decodePosts(t, rec); len(got) != tt.posts {
+				t.Errorf("got %d posts, want %d", len(got), tt.posts)
+			}
+		})
+	}
+}
+
+func TestGetPostsRecordedWebInfo(t *testing.T) {
+	srv := newFakeInstagram(t)
+	recording, err := os.ReadFile("fakeig/testdata/web_info_kopi.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Satu section per halaman, agar feed top diambil lewat kursor.
+	if err := srv.AddRecording("kopi", recording, 1); err != nil {
+		t.Fatalf("AddRecording() error = %v", err)
+	}
+
+	// Rekaman berasal dari Maret 2024, jadi limit dipasang sebelum itu.
+	rec := getPosts("hashtag=kopi&feed=both&limit=1704067200&columns=code,owner_username,created_at")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200; body: %s", rec.Code, rec.Body)
+	}
+	var body struct {
+		Posts []map[string]interface{} `json:"posts"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decoding body %q: %v", rec.Body, err)
+	}
+	codes := map[string]map[string]interface{}{}
+	for _, post := range body.Posts {
+		codes[post["code"].(string)] = post
+	}
+	// C3UxYzAbCd2 ada di top dan recent; postingan tanpa caption tidak punya waktu posting.
+	for _, code := range []string{"C3RkAbCdEf1", "C3UxYzAbCd2", "C3PqRsTuVw3", "C3VwXyZaBc5"} {
+		if codes[code] == nil {
+			t.Errorf("post %s missing from %v", code, body.Posts)
+		}
+	}
+	if len(body.Posts) != 4 {
+		t.Errorf("got %d posts, want 4 unique captioned posts", len(body.Posts))
+	}
+	if reel := codes["C3RkAbCdEf1"]; reel != nil {
+		if reel["owner_username"] != "kedai.senja" || reel["created_at"] != "2024-03-09T08:00:00Z" {
+			t.Errorf("clips post = %v, want owner kedai.senja created 2024-03-09T08:00:00Z", reel)
+		}
+	}
+	if n := len(srv.Requests()); n != 3 {
+		t.Errorf("Instagram got %d requests, want 3 (two top pages and one recent page)", n)
+	}
+}
+
+// decodePosts membaca body JSON /posts untuk satu hashtag.
+func decodePosts(t *testing.T, rec *httptest.ResponseRecorder) []split.Record {
+	t.Helper()
+	var body split.Records
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decoding body %q: %v", rec.Body, err)
+	}
+	return body.Posts
+}
+
+// assertError memeriksa status dan kode error JSON dari respons.
+func assertError(t *testing.T, rec *httptest.ResponseRecorder, status int, code string) {
+	t.Helper()
+	if rec.Code != status {
+		t.Errorf("status = %d, want %d; body: %s", rec.Code, status, rec.Body)
+	}
+	var body errorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decoding error body %q: %v", rec.Body, err)
+	}
+	if body.Code != code {
+		t.Errorf("error code = %q, want %q (%s)", body.Code, code, body.Error)
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"instagram-scraper/scraper"
+	"instagram-scraper/split"
+)
+
+// scrapeConcurrency adalah jumlah hashtag yang di-scrape bersamaan untuk satu
+// request multi-hashtag (env SCRAPE_CONCURRENCY).
+var scrapeConcurrency = scraper.DefaultConcurrency
+
+// multiPostsResponse adalah respons JSON /posts untuk beberapa hashtag.
+type multiPostsResponse struct {
+	Posts  []split.Record    `json:"posts"`
+	Counts map[string]int    `json:"counts"`           // Jumlah postingan per hashtag sebelum deduplikasi
+	Errors map[string]string `json:"errors,omitempty"` // Pesan error hashtag yang gagal
+}
+
+// writeMultiPosts men-scrape beberapa hashtag bersamaan dengan scraper.ScrapeMany
+// lalu mengirim hasil gabungannya dalam format f. Hashtag yang gagal tidak
+// menggagalkan request selama masih ada yang berhasil; error 4xx/5xx hanya
+// dikirim jika semua hashtag gagal.
+func writeMultiPosts(w http.ResponseWriter, r *http.Request, hashtags []string, scrapeOpts scraper.Options, f outputFormat, opts split.CSVOptions) {
+	result, err := scraper.ScrapeMany(r.Context(), hashtags, scrapeOpts, scrapeConcurrency)
+	if err != nil {
+		log.Printf("Error scraping hashtags %v: %v", hashtags, err)
+		writeScrapeError(w, err)
+		return
+	}
+
+	// Tanpa 'columns', tambahkan kolom hashtags agar asal setiap postingan terlihat.
+	if r.URL.Query().Get("columns") == "" {
+		opts.Columns = append(append([]split.Column(nil), split.DefaultColumns...), split.ColHashtags)
+	}
+	name := "posts_" + strings.Join(hashtags, "_")
+	if len(name) > 100 {
+		name = "posts_multi"
+	}
+
+	// Jumlah per hashtag juga dikirim sebagai header untuk format selain JSON. Hashtag
+	// boleh berisi huruf non-Latin, jadi di-percent-encode agar header tetap ASCII
+	// (formatnya sama dengan query string: tag=jumlah, dipisah koma). Pesan error
+	// lengkap hanya ada di body JSON.
+	counts := make([]string, 0, len(hashtags))
+	for _, hashtag := range hashtags {
+		if n, ok := result.Counts[hashtag]; ok {
+			counts = append(counts, url.QueryEscape(hashtag)+"="+strconv.Itoa(n))
+		}
+	}
+	w.Header().Set("X-Hashtag-Counts", strings.Join(counts, ","))
+	if len(result.Errors) > 0 {
+		failed := make([]string, 0, len(result.Errors))
+		for _, hashtag := range hashtags {
+			if _, ok := result.Errors[hashtag]; ok {
+				failed = append(failed, url.QueryEscape(hashtag))
+			}
+		}
+		w.Header().Set("X-Hashtag-Errors", strings.Join(failed, ","))
+	}
+
+	switch f.Name {
+	case formatJSON.Name:
+		resp := multiPostsResponse{
+			Posts:  split.Project(result.Posts, opts.Columns),
+			Counts: result.Counts,
+		}
+		if len(result.Errors) > 0 {
+			resp.Errors = make(map[string]string, len(result.Errors))
+			for hashtag, err := range result.Errors {
+				resp.Errors[hashtag] = err.Error()
+			}
+		}
+		w.Header().Add("Vary", "Accept")
+		writeJSON(w, http.StatusOK, resp)
+	case formatXLSX.Name:
+		// XLSX berisi sheet ringkasan dan satu sheet per hashtag (hashtag yang gagal berupa sheet kosong).
+		sheets := make([]split.XLSXSheet, len(hashtags))
+		for i, hashtag := range hashtags {
+			sheets[i] = split.XLSXSheet{Name: hashtag, Posts: result.PerTag[hashtag]}
+		}
+		if err := writePosts(w, xlsxReportFormat(sheets), name, nil, opts); err != nil {
+			log.Printf("Error writing response data: %v\n", err)
+		}
+	default:
+		if err := writePosts(w, f, name, result.Posts, opts); err != nil {
+			log.Printf("Error writing response data: %v\n", err)
+		}
+	}
+	log.Printf("Successfully processed %d hashtags and sent %d merged posts.", len(hashtags), len(result.Posts))
+}
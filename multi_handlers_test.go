@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"instagram-scraper/fakeig"
+	"instagram-scraper/split"
+)
+
+func TestGetPostsMultiPartialFailure(t *testing.T) {
+	srv := newFakeInstagram(t)
+	srv.AddPosts("kopi", split.FeedTop, 0, fakePosts(3)...)
+	srv.AddPosts("日本", split.FeedTop, 0, fakePosts(2)...)
+	srv.FailHashtag("teh", fakeig.FaultUnauthorized)
+
+	rec := getPosts("hashtag=kopi,teh,日本&format=csv")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200; body: %s", rec.Code, rec.Body)
+	}
+	if got, want := rec.Header().Get("X-Hashtag-Counts"), "kopi=3,%E6%97%A5%E6%9C%AC=2"; got != want {
+		t.Errorf("X-Hashtag-Counts = %q, want %q", got, want)
+	}
+	if got := rec.Header().Get("X-Hashtag-Errors"); got != "teh" {
+		t.Errorf("X-Hashtag-Errors = %q, want teh", got)
+	}
+
+	rec = getPosts("hashtag=kopi,teh")
+	var body multiPostsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decoding body %q: %v", rec.Body, err)
+	}
+	if want := map[string]int{"kopi": 3}; !reflect.DeepEqual(body.Counts, want) {
+		t.Errorf("counts = %v, want %v", body.Counts, want)
+	}
+	if _, ok := body.Errors["teh"]; !ok || len(body.Errors) != 1 {
+		t.Errorf("errors = %v, want only teh", body.Errors)
+	}
+	if len(body.Posts) != 3 {
+		t.Errorf("got %d posts, want 3", len(body.Posts))
+	}
+}
+
+func TestGetPostsMultiAllFail(t *testing.T) {
+	srv := newFakeInstagram(t)
+	srv.FailHashtag("kopi", fakeig.FaultUnauthorized)
+	srv.FailHashtag("teh", fakeig.FaultUnauthorized)
+
+	assertError(t, getPosts("hashtag=kopi,teh"), http.StatusBadGateway, "unauthorized")
+}
@@ -0,0 +1,49 @@
+package posts
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"sync/atomic"
+)
+
+// DefaultBaseURL adalah alamat Instagram yang dipakai jika SetBaseURL tidak dipanggil.
+const DefaultBaseURL = "https://www.instagram.com"
+
+// baseURL adalah alamat Instagram saat ini, tanpa '/' di akhir.
+var baseURL atomic.Pointer[string]
+
+// SetBaseURL mengganti alamat Instagram untuk semua request berikutnya, misalnya
+// ke server fakeig saat pengujian. raw harus http:// atau https:// dengan host;
+// path (misalnya prefix reverse proxy) dipertahankan. String kosong berarti DefaultBaseURL.
+func SetBaseURL(raw string) error {
+	if raw == "" {
+		raw = DefaultBaseURL
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid Instagram base URL %q: %w", raw, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid Instagram base URL %q: expected http(s)://host", raw)
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return fmt.Errorf("invalid Instagram base URL %q: query and fragment are not allowed", raw)
+	}
+	base := strings.TrimRight(u.String(), "/")
+	baseURL.Store(&base)
+	return nil
+}
+
+// BaseURL mengembalikan alamat Instagram yang sedang dipakai.
+func BaseURL() string {
+	if base := baseURL.Load(); base != nil {
+		return *base
+	}
+	return DefaultBaseURL
+}
+
+// instagramURL menggabungkan BaseURL dengan path yang diawali '/'.
+func instagramURL(path string) string {
+	return BaseURL() + path
+}
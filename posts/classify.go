@@ -0,0 +1,113 @@
+package posts
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// BlockedError berarti Instagram tidak menolak request dengan status error biasa,
+// melainkan meminta sesi login ulang atau verifikasi akun. Unwrap mengembalikan
+// ErrLoginWall, ErrLoginRequired atau ErrCheckpoint.
+type BlockedError struct {
+	Kind       error
+	StatusCode int
+	Detail     string // Misalnya pesan Instagram atau URL tujuan redirect
+}
+
+func (e *BlockedError) Error() string {
+	return fmt.Sprintf("%v: status %d: %s", e.Kind, e.StatusCode, e.Detail)
+}
+
+func (e *BlockedError) Unwrap() error {
+	return e.Kind
+}
+
+// igStatus adalah bentuk umum body JSON Instagram untuk request yang ditolak, misalnya
+// {"message": "checkpoint_required", "checkpoint_url": "...", "status": "fail"}.
+type igStatus struct {
+	Message       string `json:"message"`
+	Status        string `json:"status"`
+	RequireLogin  bool   `json:"require_login"`
+	CheckpointURL string `json:"checkpoint_url"`
+}
+
+// blockMarkers adalah potongan teks yang harus ada di body sebelum body 200 di-decode
+// ulang untuk diperiksa, agar halaman feed yang besar tidak selalu di-decode dua kali.
+var blockMarkers = [][]byte{[]byte("login_required"), []byte("checkpoint_required"), []byte("challenge_required"), []byte("require_login")}
+
+// classifyBlocked memeriksa apakah respons sebenarnya adalah halaman login
+// (login wall), pesan login_required, atau checkpoint/challenge, termasuk yang
+// dikirim dengan status 200. Nil jika bukan.
+func classifyBlocked(resp *http.Response, body []byte) error {
+	// Klien HTTP mengikuti redirect, jadi sesi yang logout biasanya berakhir di halaman login atau challenge.
+	if path := resp.Request.URL.Path; strings.HasPrefix(path, "/accounts/login") {
+		return &BlockedError{Kind: ErrLoginWall, StatusCode: resp.StatusCode, Detail: "redirected to " + path}
+	} else if strings.HasPrefix(path, "/challenge") {
+		return &BlockedError{Kind: ErrCheckpoint, StatusCode: resp.StatusCode, Detail: "redirected to " + path}
+	}
+
+	trimmed := bytes.TrimSpace(body)
+	if isHTML(resp.Header.Get("Content-Type"), trimmed) {
+		lower := bytes.ToLower(trimmed)
+		switch {
+		case bytes.Contains(lower, []byte("/challenge/")) || bytes.Contains(lower, []byte("checkpoint_required")):
+			return &BlockedError{Kind: ErrCheckpoint, StatusCode: resp.StatusCode, Detail: "HTML challenge page"}
+		case bytes.Contains(lower, []byte("/accounts/login")) || bytes.Contains(lower, []byte("loginform")):
+			return &BlockedError{Kind: ErrLoginWall, StatusCode: resp.StatusCode, Detail: "HTML login page"}
+		}
+		return nil
+	}
+
+	if resp.StatusCode == http.StatusOK && !containsAny(trimmed, blockMarkers) {
+		return nil
+	}
+	var status igStatus
+	if json.Unmarshal(trimmed, &status) != nil {
+		return nil
+	}
+	switch {
+	case status.Message == "checkpoint_required" || status.Message == "challenge_required" || status.CheckpointURL != "":
+		return &BlockedError{Kind: ErrCheckpoint, StatusCode: resp.StatusCode, Detail: status.Message}
+	case status.Message == "login_required" || status.RequireLogin:
+		return &BlockedError{Kind: ErrLoginRequired, StatusCode: resp.StatusCode, Detail: status.Message}
+	}
+	return nil
+}
+
+// isHTML melaporkan apakah respons berupa halaman HTML, dari Content-Type atau awal body.
+func isHTML(contentType string, body []byte) bool {
+	if strings.HasPrefix(strings.ToLower(contentType), "text/html") {
+		return true
+	}
+	prefix := body
+	if len(prefix) > 15 {
+		prefix = prefix[:15]
+	}
+	prefix = bytes.ToLower(prefix)
+	return bytes.HasPrefix(prefix, []byte("<!doctype html")) || bytes.HasPrefix(prefix, []byte("<html"))
+}
+
+func containsAny(body []byte, markers [][]byte) bool {
+	for _, m := range markers {
+		if bytes.Contains(body, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// blockedReason adalah nama singkat jenis blokir, dipakai sebagai key metrik.
+func blockedReason(kind error) string {
+	switch kind {
+	case ErrLoginWall:
+		return "login_wall"
+	case ErrLoginRequired:
+		return "login_required"
+	case ErrCheckpoint:
+		return "checkpoint_required"
+	}
+	return "unknown"
+}
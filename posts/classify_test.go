@@ -0,0 +1,118 @@
+package posts
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"instagram-scraper/session"
+)
+
+func TestClassifyBlocked(t *testing.T) {
+	const loginPage = `<!DOCTYPE html><html><head><title>Login • Instagram</title></head>` +
+		`<body><form id="loginForm" action="/accounts/login/ajax/"></form></body></html>`
+	tests := []struct {
+		name        string
+		status      int
+		path        string // Path URL akhir setelah redirect
+		contentType string
+		body        string
+		want        error // Nil jika bukan respons yang diblokir
+	}{
+		{"feed normal", 200, "/api/v1/tags/web_info/", "application/json", `{"data":{"top":{"sections":[]}}}`, nil},
+		{"caption menyebut login_required", 200, "/api/v1/tags/web_info/", "application/json", `{"data":{"caption":"login_required"}}`, nil},
+		{"redirect ke login", 200, "/accounts/login/", "text/html", loginPage, ErrLoginWall},
+		{"redirect ke challenge", 200, "/challenge/action/", "text/html", "<html></html>", ErrCheckpoint},
+		{"halaman login dengan status 200", 200, "/api/v1/tags/web_info/", "text/html; charset=utf-8", loginPage, ErrLoginWall},
+		{"halaman login tanpa Content-Type", 200, "/api/v1/tags/web_info/", "", "\n  " + loginPage, ErrLoginWall},
+		{"halaman challenge HTML", 200, "/api/v1/tags/web_info/", "text/html", `<html><a href="/challenge/abc/">verify</a></html>`, ErrCheckpoint},
+		{"HTML lain", 200, "/api/v1/tags/web_info/", "text/html", "<html><body>maintenance</body></html>", nil},
+		{"login_required 401", 401, "/api/v1/tags/web_info/", "application/json", `{"message":"login_required","status":"fail"}`, ErrLoginRequired},
+		{"login_required dengan status 200", 200, "/api/v1/tags/web_info/", "application/json", `{"message":"login_required","status":"fail"}`, ErrLoginRequired},
+		{"require_login", 200, "/api/v1/tags/web_info/", "application/json", `{"require_login":true,"status":"fail"}`, ErrLoginRequired},
+		{"checkpoint_required 400", 400, "/api/v1/tags/web_info/", "application/json", `{"message":"checkpoint_required","checkpoint_url":"/challenge/x/"}`, ErrCheckpoint},
+		{"challenge_required", 400, "/api/v1/tags/web_info/", "application/json", `{"message":"challenge_required"}`, ErrCheckpoint},
+		{"checkpoint_url saja", 400, "/api/v1/tags/web_info/", "application/json", `{"message":"","checkpoint_url":"/challenge/x/"}`, ErrCheckpoint},
+		{"403 biasa", 403, "/api/v1/tags/web_info/", "application/json", `{"message":"forbidden","status":"fail"}`, nil},
+		{"429", 429, "/api/v1/tags/web_info/", "application/json", `{"message":"Please wait a few minutes","status":"fail"}`, nil},
+		{"body bukan JSON", 500, "/api/v1/tags/web_info/", "text/plain", "oops", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{
+				StatusCode: tt.status,
+				Header:     http.Header{},
+				Request:    &http.Request{URL: &url.URL{Scheme: "https", Host: "www.instagram.com", Path: tt.path}},
+			}
+			if tt.contentType != "" {
+				resp.Header.Set("Content-Type", tt.contentType)
+			}
+			err := classifyBlocked(resp, []byte(tt.body))
+			if tt.want == nil {
+				if err != nil {
+					t.Errorf("classifyBlocked() = %v, want nil", err)
+				}
+				return
+			}
+			var blockedErr *BlockedError
+			if !errors.Is(err, tt.want) || !errors.As(err, &blockedErr) {
+				t.Fatalf("classifyBlocked() = %v, want BlockedError wrapping %v", err, tt.want)
+			}
+			if blockedErr.StatusCode != tt.status {
+				t.Errorf("BlockedError.StatusCode = %d, want %d", blockedErr.StatusCode, tt.status)
+			}
+		})
+	}
+}
+
+func TestBlockedReason(t *testing.T) {
+	tests := map[error]string{
+		ErrLoginWall:     "login_wall",
+		ErrLoginRequired: "login_required",
+		ErrCheckpoint:    "checkpoint_required",
+		ErrUpstream:      "unknown",
+	}
+	for kind, want := range tests {
+		if got := blockedReason(kind); got != want {
+			t.Errorf("blockedReason(%v) = %q, want %q", kind, got, want)
+		}
+	}
+}
+
+func TestFetcherDoBlockedQuarantinesSession(t *testing.T) {
+	SetRateLimit(RateLimit{}, RateLimit{})
+	defer SetRateLimit(DefaultRateLimit, DefaultSessionRateLimit)
+
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"message":"checkpoint_required","status":"fail"}`))
+	}))
+	defer srv.Close()
+
+	pool, err := session.NewPool(session.Config{Sessions: []session.Session{
+		{Name: "a", Cookie: "sessionid=a"},
+		{Name: "b", Cookie: "sessionid=b"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := newTestFetcher(srv, RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond})
+	f.pool = pool
+	f.session, _ = pool.Acquire()
+
+	if _, err := f.do(mustRequest(t, srv.URL)); !errors.Is(err, ErrCheckpoint) {
+		t.Fatalf("do() error = %v, want ErrCheckpoint", err)
+	}
+	// Tidak ada retry untuk sesi yang sama; hanya failover sekali ke sesi b.
+	if calls != 2 {
+		t.Errorf("server got %d requests, want 2 (one per session, blocked responses are not retried)", calls)
+	}
+	if _, err := pool.Acquire(); !errors.Is(err, session.ErrNoSession) {
+		t.Errorf("Acquire() after checkpoint error = %v, want ErrNoSession", err)
+	}
+}
@@ -0,0 +1,57 @@
+package posts
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+var (
+	// ErrUnauthorized berarti Instagram menolak sesi (401/403): header/cookie kedaluwarsa atau tidak valid.
+	ErrUnauthorized = errors.New("instagram rejected the session credentials")
+	// ErrLoginWall berarti Instagram membalas dengan halaman login HTML (sering dengan
+	// status 200 atau lewat redirect), biasanya karena cookie sesi kedaluwarsa.
+	ErrLoginWall = errors.New("instagram returned a login page (session logged out)")
+	// ErrLoginRequired berarti Instagram membalas JSON "login_required".
+	ErrLoginRequired = errors.New("instagram requires login (session logged out)")
+	// ErrCheckpoint berarti akun sesi diminta verifikasi ("checkpoint_required" atau challenge).
+	ErrCheckpoint = errors.New("instagram account requires a checkpoint")
+	// ErrRateLimited berarti Instagram membalas 429 Too Many Requests.
+	ErrRateLimited = errors.New("instagram rate limit reached")
+	// ErrUpstream mencakup kegagalan lain saat berkomunikasi dengan Instagram
+	// (error jaringan atau status non-200 lainnya).
+	ErrUpstream = errors.New("instagram request failed")
+)
+
+// StatusError menyimpan status HTTP dan potongan body dari respons Instagram yang gagal.
+// Unwrap mengembalikan salah satu error sentinel di atas sehingga bisa dicek dengan errors.Is.
+type StatusError struct {
+	StatusCode int
+	Body       string
+	RetryAfter time.Duration // Dari header Retry-After, 0 jika tidak ada
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%v: status %d: %s", e.Unwrap(), e.StatusCode, e.Body)
+}
+
+func (e *StatusError) Unwrap() error {
+	switch e.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrUnauthorized
+	case http.StatusTooManyRequests:
+		return ErrRateLimited
+	}
+	return ErrUpstream
+}
+
+// maxErrorBody membatasi panjang body yang disimpan di StatusError agar log tidak membengkak.
+const maxErrorBody = 512
+
+func newStatusError(statusCode int, body []byte) *StatusError {
+	if len(body) > maxErrorBody {
+		body = body[:maxErrorBody]
+	}
+	return &StatusError{StatusCode: statusCode, Body: string(body)}
+}
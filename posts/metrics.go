@@ -0,0 +1,27 @@
+package posts
+
+import "expvar"
+
+// Metrik request ke Instagram, dipublikasikan lewat expvar (lihat GET /debug/vars).
+var (
+	// metricRequests menghitung setiap percobaan HTTP ke Instagram, termasuk retry.
+	metricRequests = expvar.NewInt("instagram_requests_total")
+	// metricRetries menghitung retry per alasan ("429", "503", "network", ...).
+	metricRetries = expvar.NewMap("instagram_retries_total")
+	// metricRetriesExhausted menghitung request yang tetap gagal setelah retry dihentikan.
+	metricRetriesExhausted = expvar.NewMap("instagram_retries_exhausted_total")
+	// metricRetryWaitMillis menjumlahkan total waktu tunggu sebelum retry, dalam milidetik.
+	metricRetryWaitMillis = expvar.NewInt("instagram_retry_wait_ms_total")
+	// metricNetworkFailures menghitung request yang gagal di jaringan menuju Instagram (bukan di proxy).
+	metricNetworkFailures = expvar.NewInt("instagram_network_failures_total")
+	// metricProxyFailures menghitung kegagalan proxy keluar per host:port proxy.
+	metricProxyFailures = expvar.NewMap("instagram_proxy_failures_total")
+	// metricBlocked menghitung respons login wall, login_required dan checkpoint per jenisnya.
+	metricBlocked = expvar.NewMap("instagram_blocked_total")
+	// metricFailovers menghitung request yang diulang dengan sesi lain karena sesinya ditolak.
+	metricFailovers = expvar.NewInt("instagram_session_failovers_total")
+	// metricRateLimitWaits menghitung request yang harus menunggu rate limiter.
+	metricRateLimitWaits = expvar.NewInt("instagram_rate_limit_waits_total")
+	// metricRateLimitWaitMillis menjumlahkan total waktu tunggu rate limiter, dalam milidetik.
+	metricRateLimitWaitMillis = expvar.NewInt("instagram_rate_limit_wait_ms_total")
+)
@@ -1,64 +1,277 @@
 package posts
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log" // Pastikan ini diimpor
 	"net/http"
-	"os"
+	"net/url"
+	"path/filepath"
+	"strings"
 	"time"
+
+	"instagram-scraper/atomicfile"
+	"instagram-scraper/session"
+	"instagram-scraper/split"
+	"instagram-scraper/tagname"
 )
 
+// DefaultMaxPages dipakai ketika Options.MaxPages bernilai 0, supaya paginasi
+// tidak pernah berjalan tanpa batas.
+const DefaultMaxPages = 10
+
+// Options mengatur seberapa jauh Posts mengikuti kursor paginasi Instagram.
+type Options struct {
+	MaxPages int        // Jumlah halaman maksimum (termasuk halaman pertama). 0 = DefaultMaxPages
+	MaxPosts int        // Paginasi feed berhenti setelah sebanyak ini postingan terkumpul. 0 = tanpa batas
+	Limit    int64      // Unix timestamp. Berhenti jika semua postingan di satu halaman lebih lama dari ini
+	Feed     split.Feed // Feed yang diambil: top, recent, atau both. Kosong = top
+
+	// Retry mengatur pengulangan request yang gagal sementara. Nilai 0 = DefaultRetryPolicy.
+	Retry RetryPolicy
+
+	// OnPage, jika di-set, dipanggil setiap kali satu halaman feed selesai diambil dan
+	// di-decode, sebelum halaman berikutnya diminta. Dipanggil dari goroutine pemanggil Fetch.
+	OnPage func(Page)
+}
+
+// Page adalah satu halaman feed yang baru saja diambil.
+type Page struct {
+	Feed     string          // "top" atau "recent"
+	Number   int             // Nomor halaman di feed ini, mulai dari 1
+	Sections []split.Section // Sections di halaman ini saja
+}
+
+// Result adalah hasil Fetch: respons web_info dengan semua halaman sudah digabung.
+type Result struct {
+	// Raw adalah JSON mentah dari Instagram. Field yang tidak dikenal TopLevelResponse tetap ada.
+	Raw map[string]interface{}
+	// Response adalah versi ter-decode dari Raw. Nil jika strukturnya tidak cocok dengan
+	// split.TopLevelResponse (dalam hal ini hanya halaman pertama yang diambil).
+	Response *split.TopLevelResponse
+}
+
 // Posts mencoba mengambil data postingan Instagram berdasarkan hashtag
-// dan menyimpannya ke file JSON.
-func Posts(hashtag string) {
-	url := "https://www.instagram.com/api/v1/tags/web_info/?tag_name=" + hashtag
+// dan menyimpannya ke file JSON <dir>/posts_<hashtag>.json.
+// Untuk pemakaian sebagai library tanpa file, gunakan Fetch.
+func Posts(dir, hashtag string, opts Options) error {
+	result, err := Fetch(context.Background(), hashtag, opts)
+	if err != nil {
+		return err
+	}
+	return SaveRaw(dir, hashtag, result.Raw)
+}
+
+// Fetch mengambil data postingan Instagram berdasarkan hashtag dan mengembalikannya
+// di memori.
+//
+// Halaman pertama diambil dari endpoint web_info. Untuk setiap feed yang dipilih
+// ("top" dan/atau "recent"), selama "more_available" bernilai true halaman
+// berikutnya diambil dari endpoint sections memakai kursor next_max_id /
+// next_page / next_media_ids, lalu section-nya digabung ke "data.<feed>.sections"
+// sehingga hasilnya tetap berbentuk satu respons web_info. Batas MaxPages dan
+// MaxPosts berlaku per feed dan dicek per halaman, jadi Raw bisa berisi lebih
+// dari MaxPosts postingan; scraper.Stream memotong hasil ekstraksinya.
+//
+// Kegagalan pada halaman pertama dikembalikan sebagai error (lihat ErrUnauthorized,
+// ErrRateLimited, ErrUpstream dan split.ErrSchemaChanged). Kegagalan pada halaman
+// berikutnya hanya dicatat di log; halaman yang sudah terkumpul tetap dikembalikan.
+// Pembatalan ctx menghentikan request yang sedang berjalan. hashtag dinormalkan
+// dengan tagname.Normalize; hashtag yang tidak valid menghasilkan tagname.ErrInvalid.
+func Fetch(ctx context.Context, hashtag string, opts Options) (*Result, error) {
+	hashtag, err := tagname.Normalize(hashtag)
+	if err != nil {
+		return nil, err
+	}
+	maxPages := opts.MaxPages
+	if maxPages <= 0 {
+		maxPages = DefaultMaxPages
+	}
+
+	webInfoURL := instagramURL("/api/v1/tags/web_info/?tag_name=" + url.QueryEscape(hashtag))
 
 	log.Printf("Attempting to fetch data for hashtag '%s' from Instagram API...", hashtag)
 
-	req, err := http.NewRequest("GET", url, nil)
+	// Semua halaman satu Fetch memakai sesi yang sama dari pool, kecuali sesi
+	// tersebut ditolak Instagram di tengah jalan (lihat fetcher.failover).
+	sessions := sessionPool()
+	sess, err := sessions.Acquire()
+	if err != nil {
+		log.Printf("Error acquiring Instagram session for %s: %v\n", hashtag, err)
+		return nil, err
+	}
+	proxy, err := proxyFor(sess)
+	if err != nil {
+		return nil, fmt.Errorf("session %s: %w", sess.Name, err)
+	}
+	if proxy != nil {
+		log.Printf("Using Instagram session '%s' via proxy %s for hashtag '%s'.", sess.Name, proxy.Host, hashtag)
+	} else {
+		log.Printf("Using Instagram session '%s' for hashtag '%s'.", sess.Name, hashtag)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", webInfoURL, nil)
 	if err != nil {
 		log.Printf("Error creating HTTP request for %s: %v\n", hashtag, err)
-		return
+		return nil, fmt.Errorf("creating request for %s: %w", hashtag, err)
+	}
+	setHeaders(req, hashtag, sess)
+
+	f := &fetcher{
+		ctx:     ctx,
+		client:  &http.Client{Timeout: 30 * time.Second, Transport: transportFor(proxy)},
+		hashtag: hashtag,
+		proxy:   proxy,
+		pool:    sessions,
+		session: sess,
+		retry:   opts.Retry.withDefaults(),
+	}
+	body, err := f.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{}
+	if err := json.Unmarshal(body, &result.Raw); err != nil {
+		log.Printf("Error decoding JSON response for %s: %v\n", hashtag, err)
+		log.Printf("Raw response body: %s\n", string(body))
+		return nil, fmt.Errorf("%w: decoding web_info for %s: %v", split.ErrSchemaChanged, hashtag, err)
 	}
+	log.Println("JSON response successfully decoded.")
+
+	// Decode ulang ke struct yang sudah diketahui untuk membaca kursor dan timestamp.
+	var first split.TopLevelResponse
+	if err := json.Unmarshal(body, &first); err != nil {
+		log.Printf("WARNING: Could not decode pagination cursors for %s (%v). Returning first page only.", hashtag, err)
+		return result, nil
+	}
+	result.Response = &first
+
+	f.onPage = opts.OnPage
+	for _, tab := range opts.Feed.Tabs() {
+		f.paginate(tab, result, opts.MaxPosts, maxPages, opts.Limit)
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// fetcher menyimpan state yang dipakai bersama selama satu kali Fetch.
+type fetcher struct {
+	ctx     context.Context
+	client  *http.Client
+	hashtag string
+	proxy   *url.URL // Proxy keluar yang dipakai client. Nil = tanpa proxy
+	pool    *session.Pool
+	session session.Session // Sesi yang dipakai request berikutnya. Berganti hanya saat failover
+	tried   map[string]bool // Sesi yang sudah ditolak selama Fetch ini (lihat failover)
+	retry   RetryPolicy
+	onPage  func(Page)
+}
+
+// emit meneruskan halaman ke callback OnPage, jika ada.
+func (f *fetcher) emit(tab string, number int, sections []split.Section) {
+	if f.onPage != nil {
+		f.onPage(Page{Feed: tab, Number: number, Sections: sections})
+	}
+}
+
+// paginate mengikuti kursor paginasi satu feed (tab "top" atau "recent") mulai
+// dari halaman pertama yang sudah ada di respons web_info. Setiap halaman baru
+// digabung ke result.Raw dan result.Response.
+func (f *fetcher) paginate(tab string, result *Result, maxPosts, maxPages int, limit int64) {
+	hashtag := f.hashtag
+	feed := result.Response.FeedSections(tab)
+	page := *feed
+	pageNum := 1
+	if len(page.Sections) == 0 && page.NextMaxID == "" {
+		// web_info tidak selalu menyertakan isi feed ini (terutama "recent"),
+		// jadi halaman pertamanya diambil langsung dari endpoint sections.
+		first, rawSections, err := f.fetchSectionsPage(tab, page)
+		if err != nil {
+			log.Printf("WARNING: Could not fetch first page of '%s' feed for hashtag '%s': %v", tab, hashtag, err)
+			return
+		}
+		mergeSections(result, tab, rawSections, first)
+		page = first
+	}
+
+	f.emit(tab, pageNum, page.Sections)
+	postCount, allOlder := countMedias(page.Sections, limit)
+	log.Printf("Page %d of '%s' feed for hashtag '%s': %d posts, more_available=%t", pageNum, tab, hashtag, postCount, page.MoreAvailable)
+
+	for pageNum = 2; pageNum <= maxPages; pageNum++ {
+		if !page.MoreAvailable || page.NextMaxID == "" {
+			log.Printf("No more pages available in '%s' feed for hashtag '%s'.", tab, hashtag)
+			break
+		}
+		if maxPosts > 0 && postCount >= maxPosts {
+			log.Printf("Reached max posts budget (%d) in '%s' feed for hashtag '%s'.", maxPosts, tab, hashtag)
+			break
+		}
+		if allOlder {
+			log.Printf("All posts on the last page of '%s' feed for hashtag '%s' are older than limit %d. Stopping pagination.", tab, hashtag, limit)
+			break
+		}
+
+		next, rawSections, err := f.fetchSectionsPage(tab, page)
+		if err != nil {
+			log.Printf("WARNING: Stopping pagination of '%s' feed for hashtag '%s' at page %d: %v", tab, hashtag, pageNum, err)
+			break
+		}
+		mergeSections(result, tab, rawSections, next)
+		f.emit(tab, pageNum, next.Sections)
+
+		n, older := countMedias(next.Sections, limit)
+		postCount += n
+		allOlder = older
+		page = next
+		log.Printf("Page %d of '%s' feed for hashtag '%s': %d posts (total %d), more_available=%t", pageNum, tab, hashtag, n, postCount, page.MoreAvailable)
+	}
+}
 
-	cookie := os.Getenv("COOKIE")
-	if cookie == "" {
-		log.Println("WARNING: COOKIE environment variable is not set. Request might fail.")
+// setHeaders memasang header sesi browser (cookie, csrftoken, dll.) dari sess.
+func setHeaders(req *http.Request, hashtag string, sess session.Session) {
+	if sess.Cookie == "" {
+		log.Printf("WARNING: Session '%s' has no cookie (COOKIE environment variable is not set?). Request might fail.", sess.Name)
 	}
-	req.Header.Set("cookie", cookie)
+	req.Header.Set("cookie", sess.Cookie)
 
-	if ua := os.Getenv("USER_AGENT"); ua != "" {
-		req.Header.Set("User-Agent", ua)
+	if sess.UserAgent != "" {
+		req.Header.Set("User-Agent", sess.UserAgent)
 	} else {
 		req.Header.Set("User-Agent", "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36") // Fallback
 	}
 
-	if asbdID := os.Getenv("X_ASBD_ID"); asbdID != "" {
-		req.Header.Set("X-ASBD_ID", asbdID)
+	if sess.ASBDID != "" {
+		req.Header.Set("X-ASBD_ID", sess.ASBDID)
 	} else {
-		log.Println("WARNING: X_ASBD_ID environment variable is not set.")
+		log.Printf("WARNING: Session '%s' has no asbd_id (X_ASBD_ID).", sess.Name)
 	}
-	if csrfToken := os.Getenv("X_CSRFTOKEN"); csrfToken != "" {
-		req.Header.Set("X-CSRFTOKEN", csrfToken)
+	if sess.CSRFToken != "" {
+		req.Header.Set("X-CSRFTOKEN", sess.CSRFToken)
 	} else {
-		log.Println("WARNING: X_CSRFTOKEN environment variable is not set.")
+		log.Printf("WARNING: Session '%s' has no csrftoken (X_CSRFTOKEN).", sess.Name)
 	}
-	if igAppID := os.Getenv("X_IG_APP_ID"); igAppID != "" {
-		req.Header.Set("X-IG_APP_ID", igAppID)
+	if sess.AppID != "" {
+		req.Header.Set("X-IG_APP_ID", sess.AppID)
 	} else {
-		log.Println("WARNING: X_IG_APP_ID environment variable is not set.")
+		log.Printf("WARNING: Session '%s' has no app_id (X_IG_APP_ID).", sess.Name)
 	}
-	if igWWWClaim := os.Getenv("X_IG_WWW_CLAIM"); igWWWClaim != "" {
-		req.Header.Set("X-IG_WWW_CLAIM", igWWWClaim)
+	if sess.WWWClaim != "" {
+		req.Header.Set("X-IG_WWW_CLAIM", sess.WWWClaim)
 	} else {
-		log.Println("WARNING: X_IG_WWW_CLAIM environment variable is not set.")
+		log.Printf("WARNING: Session '%s' has no www_claim (X_IG_WWW_CLAIM).", sess.Name)
 	}
 
 	req.Header.Set("Accept", "*/*")
 	req.Header.Set("Accept-Language", "en-US,en;q=0.9")
-	req.Header.Set("Referer", "https://www.instagram.com/explore/tags/"+hashtag+"/")
+	req.Header.Set("Referer", instagramURL("/explore/tags/"+url.PathEscape(hashtag)+"/"))
 	req.Header.Set("Sec-Ch-Ua", `"Not/A)Brand";v="8", "Chromium";v="126", "Google Chrome";v="126"`)
 	req.Header.Set("Sec-Ch-Ua-Mobile", "?0")
 	req.Header.Set("Sec-Ch-Ua-Platform", "Linux")
@@ -66,54 +279,294 @@ func Posts(hashtag string) {
 	req.Header.Set("Sec-Fetch-Mode", "cors")
 	req.Header.Set("Sec-Fetch-Site", "same-origin")
 	req.Header.Set("X-Requested-With", "XMLHttpRequest")
+}
+
+// do menjalankan request dan mengembalikan body jika status 200, mengulanginya
+// sesuai f.retry jika gagal sementara. Status lain dikembalikan sebagai *StatusError.
+// Jika sesinya ditolak Instagram, request diulang dengan sesi sehat berikutnya
+// dari pool sebelum menyerah.
+func (f *fetcher) do(req *http.Request) ([]byte, error) {
+	for {
+		body, err := f.retrySession(req)
+		if err == nil || !rejectsSession(err) || !f.failover() {
+			return body, err
+		}
+		if req, err = f.rewind(req); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// retrySession adalah isi do untuk satu sesi: percobaan berulang sesuai f.retry.
+func (f *fetcher) retrySession(req *http.Request) ([]byte, error) {
+	hashtag := f.hashtag
+	start := time.Now()
+	for attempt := 1; ; attempt++ {
+		body, err := f.attempt(req)
+		if err == nil {
+			return body, nil
+		}
+		reason, ok := retryable(err)
+		if !ok {
+			return nil, err
+		}
+
+		wait := f.retry.backoff(attempt)
+		var statusErr *StatusError
+		if errors.As(err, &statusErr) && statusErr.RetryAfter > 0 {
+			wait = statusErr.RetryAfter
+		}
+		if attempt >= f.retry.MaxAttempts || time.Since(start)+wait > f.retry.MaxElapsed {
+			log.Printf("Giving up on request for hashtag '%s' after %d attempts in %s: %v", hashtag, attempt, time.Since(start).Round(time.Millisecond), err)
+			metricRetriesExhausted.Add(reason, 1)
+			return nil, err
+		}
+		log.Printf("Retrying request for hashtag '%s' in %s (attempt %d/%d failed: %s)", hashtag, wait.Round(time.Millisecond), attempt, f.retry.MaxAttempts, reason)
+		metricRetries.Add(reason, 1)
+		metricRetryWaitMillis.Add(wait.Milliseconds())
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-f.ctx.Done():
+			timer.Stop()
+			return nil, f.ctx.Err()
+		}
 
-	client := &http.Client{
-		Timeout: 30 * time.Second,
+		if req, err = f.rewind(req); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// rewind membuat ulang req untuk percobaan berikutnya: body request (form sections)
+// sudah terbaca, dan header sesi diisi lagi karena cookie/claim mungkin sudah
+// diperbarui respons sebelumnya atau sesinya sudah diganti failover.
+func (f *fetcher) rewind(req *http.Request) (*http.Request, error) {
+	next := req.Clone(f.ctx)
+	if req.GetBody != nil {
+		var err error
+		if next.Body, err = req.GetBody(); err != nil {
+			return nil, fmt.Errorf("rewinding request body: %w", err)
+		}
+	}
+	setHeaders(next, f.hashtag, f.session)
+	return next, nil
+}
+
+// failover mengganti f.session dengan sesi sehat berikutnya dari pool setelah
+// sesi saat ini ditolak (dan dikarantina). false jika tidak ada sesi lain yang
+// belum dicoba di Fetch ini.
+func (f *fetcher) failover() bool {
+	if f.pool.Len() < 2 {
+		return false
+	}
+	if f.tried == nil {
+		f.tried = make(map[string]bool)
 	}
-	resp, err := client.Do(req)
+	f.tried[f.session.Name] = true
+	sess, err := f.pool.Acquire()
+	if err != nil || f.tried[sess.Name] {
+		return false
+	}
+	proxy, err := proxyFor(sess)
 	if err != nil {
+		log.Printf("WARNING: Cannot fail over hashtag '%s' to Instagram session '%s': %v", f.hashtag, sess.Name, err)
+		return false
+	}
+	log.Printf("Instagram session '%s' was rejected; retrying hashtag '%s' with session '%s'.", f.session.Name, f.hashtag, sess.Name)
+	metricFailovers.Add(1)
+	if !sameProxy(proxy, f.proxy) {
+		f.client = &http.Client{Timeout: f.client.Timeout, Transport: transportFor(proxy)}
+	}
+	f.session, f.proxy = sess, proxy
+	return true
+}
+
+// attempt menjalankan request satu kali, setelah menunggu giliran dari rate limiter.
+func (f *fetcher) attempt(req *http.Request) ([]byte, error) {
+	hashtag := f.hashtag
+	waited, err := limiters.Load().wait(f.ctx, f.session.Name)
+	if err != nil {
+		return nil, err
+	}
+	if waited > 0 {
+		metricRateLimitWaits.Add(1)
+		metricRateLimitWaitMillis.Add(waited.Milliseconds())
+		if waited >= time.Second {
+			log.Printf("Rate limiter delayed request for hashtag '%s' by %s.", hashtag, waited.Round(time.Millisecond))
+		}
+	}
+	metricRequests.Add(1)
+	resp, err := f.client.Do(req)
+	if err != nil {
+		if ctxErr := f.ctx.Err(); ctxErr != nil {
+			log.Printf("Error performing HTTP request for %s: %v\n", hashtag, err)
+			return nil, ctxErr
+		}
+		// Kegagalan proxy dibedakan dari kegagalan Instagram, di error maupun metrik.
+		if proxyErr, ok := asProxyError(err, f.proxy); ok {
+			log.Printf("Proxy %s failed for request of hashtag '%s' (session '%s'): %v\n", f.proxy.Host, hashtag, f.session.Name, err)
+			metricProxyFailures.Add(f.proxy.Host, 1)
+			return nil, proxyErr
+		}
 		log.Printf("Error performing HTTP request for %s: %v\n", hashtag, err)
-		return
+		metricNetworkFailures.Add(1)
+		return nil, fmt.Errorf("%w: %v", ErrUpstream, err)
 	}
 	defer resp.Body.Close()
+	// Instagram merotasi csrftoken lewat Set-Cookie dan www-claim lewat header respons,
+	// termasuk pada respons gagal. Request berikutnya memakai nilai yang baru.
+	f.session = f.pool.Absorb(f.session, resp)
 
 	if resp.StatusCode != http.StatusOK {
 		errorBody, _ := io.ReadAll(resp.Body)
 		log.Printf("HTTP request for %s failed with status code %d: %s\n", hashtag, resp.StatusCode, string(errorBody))
-		return
+		if err := classifyBlocked(resp, errorBody); err != nil {
+			return nil, f.blocked(err)
+		}
+		statusErr := newStatusError(resp.StatusCode, errorBody)
+		statusErr.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+		if rejectsSession(statusErr) {
+			f.pool.Quarantine(f.session.Name, statusErr)
+		}
+		return nil, statusErr
 	}
 	log.Printf("Successfully received HTTP response for hashtag '%s'. Status: %d", hashtag, resp.StatusCode)
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		log.Printf("Error reading response body for %s: %v\n", hashtag, err)
-		return
+		return nil, fmt.Errorf("%w: reading body: %v", ErrUpstream, err)
 	}
 	log.Printf("Response body read. Size: %d bytes.", len(body))
+	// Sesi yang logout sering tetap mendapat status 200, tetapi isinya halaman login atau checkpoint.
+	if err := classifyBlocked(resp, body); err != nil {
+		return nil, f.blocked(err)
+	}
+	f.pool.ReportSuccess(f.session.Name)
+	return body, nil
+}
 
+// blocked mencatat respons login wall/login_required/checkpoint di log dan metrik,
+// lalu mengkarantina sesinya.
+func (f *fetcher) blocked(err error) error {
+	log.Printf("ERROR: Instagram session '%s' is blocked while fetching hashtag '%s': %v", f.session.Name, f.hashtag, err)
+	var blockedErr *BlockedError
+	if errors.As(err, &blockedErr) {
+		metricBlocked.Add(blockedReason(blockedErr.Kind), 1)
+	}
+	f.pool.Quarantine(f.session.Name, err)
+	return err
+}
 
-	var result map[string]interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		log.Printf("Error decoding JSON response for %s: %v\n", hashtag, err)
-		log.Printf("Raw response body: %s\n", string(body))
-		return
+// fetchSectionsPage mengambil halaman berikutnya dari endpoint sections memakai
+// kursor dari halaman sebelumnya. Section mentah dikembalikan juga agar bisa
+// digabung ke hasil tanpa kehilangan field yang tidak dikenal struct.
+func (f *fetcher) fetchSectionsPage(tab string, prev split.FeedSections) (split.FeedSections, []interface{}, error) {
+	hashtag := f.hashtag
+	var page split.FeedSections
+
+	nextMediaIDs, _ := json.Marshal(prev.NextMediaIDs)
+	form := url.Values{}
+	form.Set("include_persistent", "0")
+	if prev.NextMaxID != "" {
+		form.Set("max_id", prev.NextMaxID)
+		form.Set("page", fmt.Sprint(prev.NextPage))
+		form.Set("next_media_ids", string(nextMediaIDs))
 	}
-	log.Println("JSON response successfully decoded.")
+	form.Set("surface", "grid")
+	form.Set("tab", tab)
 
+	sectionsURL := instagramURL("/api/v1/tags/" + url.PathEscape(hashtag) + "/sections/")
+	req, err := http.NewRequestWithContext(f.ctx, "POST", sectionsURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		log.Printf("Error creating sections request for %s: %v\n", hashtag, err)
+		return page, nil, fmt.Errorf("creating sections request for %s: %w", hashtag, err)
+	}
+	setHeaders(req, hashtag, f.session)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
-	fileName := fmt.Sprintf("/app/output/posts_%s.json", hashtag)
-	file, err := os.Create(fileName)
+	body, err := f.do(req)
 	if err != nil {
-		log.Printf("Error creating JSON file %s: %v\n", fileName, err)
+		return page, nil, err
+	}
+
+	if err := json.Unmarshal(body, &page); err != nil {
+		log.Printf("Error decoding sections page for %s: %v\n", hashtag, err)
+		return page, nil, fmt.Errorf("%w: decoding sections page for %s: %v", split.ErrSchemaChanged, hashtag, err)
+	}
+	var raw struct {
+		Sections []interface{} `json:"sections"`
+	}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		log.Printf("Error decoding raw sections for %s: %v\n", hashtag, err)
+		return page, nil, fmt.Errorf("%w: decoding raw sections for %s: %v", split.ErrSchemaChanged, hashtag, err)
+	}
+	return page, raw.Sections, nil
+}
+
+// mergeSections menambahkan section halaman baru ke "data.<tab>.sections" dan
+// memperbarui kursor paginasi feed tersebut di hasil gabungan, baik di Raw
+// maupun di Response.
+func mergeSections(result *Result, tab string, rawSections []interface{}, page split.FeedSections) {
+	feedSections := result.Response.FeedSections(tab)
+	sections := append(feedSections.Sections, page.Sections...)
+	*feedSections = page
+	feedSections.Sections = sections
+
+	data, _ := result.Raw["data"].(map[string]interface{})
+	if data == nil {
 		return
 	}
-	defer file.Close()
+	feed, _ := data[tab].(map[string]interface{})
+	if feed == nil {
+		feed = map[string]interface{}{}
+		data[tab] = feed
+	}
+	existing, _ := feed["sections"].([]interface{})
+	feed["sections"] = append(existing, rawSections...)
+	feed["more_available"] = page.MoreAvailable
+	feed["next_max_id"] = page.NextMaxID
+	feed["next_page"] = page.NextPage
+	feed["next_media_ids"] = page.NextMediaIDs
+}
 
-	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "    ")
-	if err := encoder.Encode(result); err != nil {
+// countMedias menghitung jumlah media di sections dan melaporkan apakah semuanya
+// lebih lama dari limit (halaman kosong tidak dianggap "lebih lama").
+func countMedias(sections []split.Section, limit int64) (int, bool) {
+	count := 0
+	allOlder := true
+	for _, section := range sections {
+		for _, media := range section.Medias() {
+			count++
+			if media.Caption.CreatedAt >= limit {
+				allOlder = false
+			}
+		}
+	}
+	return count, count > 0 && allOlder
+}
+
+// SaveRaw menulis respons mentah (yang sudah digabung) ke <dir>/posts_<hashtag>.json,
+// dengan hashtag dalam bentuk normalnya (lihat tagname.Normalize) sehingga nama
+// file tidak pernah keluar dari dir.
+func SaveRaw(dir, hashtag string, result map[string]interface{}) error {
+	hashtag, err := tagname.Normalize(hashtag)
+	if err != nil {
+		return err
+	}
+	fileName := filepath.Join(dir, fmt.Sprintf("posts_%s.json", hashtag))
+	// Ditulis secara atomik agar request lain tidak pernah membaca JSON yang setengah jadi.
+	err = atomicfile.Write(fileName, func(w io.Writer) error {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "    ")
+		return encoder.Encode(result)
+	})
+	if err != nil {
 		log.Printf("Error writing to JSON file %s: %v\n", fileName, err)
-		return
+		return fmt.Errorf("writing %s: %w", fileName, err)
 	}
 	log.Printf("Raw data for hashtag '%s' saved to '%s'", hashtag, fileName)
+	return nil
 }
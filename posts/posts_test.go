@@ -0,0 +1,214 @@
+package posts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"instagram-scraper/fakeig"
+	"instagram-scraper/session"
+	"instagram-scraper/split"
+)
+
+// newFakeFetcher menjalankan fakeig dan membuat fetcher untuk hashtag "kopi"
+// yang diarahkan ke sana, tanpa rate limit.
+func newFakeFetcher(t *testing.T) (*fakeig.Server, *fetcher) {
+	t.Helper()
+	srv := fakeig.New()
+	t.Cleanup(srv.Close)
+	if err := SetBaseURL(srv.URL); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { SetBaseURL("") })
+	SetRateLimit(RateLimit{}, RateLimit{})
+	t.Cleanup(func() { SetRateLimit(DefaultRateLimit, DefaultSessionRateLimit) })
+
+	sess := session.Session{Name: "test", Cookie: "sessionid=x"}
+	return srv, &fetcher{
+		ctx:     context.Background(),
+		client:  srv.Client(),
+		hashtag: "kopi",
+		pool:    session.Single(sess),
+		session: sess,
+		retry:   RetryPolicy{MaxAttempts: 1}.withDefaults(),
+	}
+}
+
+// emptyResult adalah respons web_info tanpa isi feed, sehingga paginate
+// mengambil halaman pertama dari endpoint sections.
+func emptyResult() *Result {
+	return &Result{
+		Raw:      map[string]interface{}{"data": map[string]interface{}{}},
+		Response: &split.TopLevelResponse{},
+	}
+}
+
+// timedPosts membuat postingan P1..Pn dengan created_at dari createdAt(i).
+func timedPosts(n int, createdAt func(i int) int64) []fakeig.Post {
+	out := make([]fakeig.Post, n)
+	for i := range out {
+		out[i] = fakeig.Post{Code: fmt.Sprintf("P%d", i+1), Username: "budi", CreatedAt: createdAt(i)}
+	}
+	return out
+}
+
+func TestPaginate(t *testing.T) {
+	// Halaman 1 berisi P1-P4 (waktu 1000), halaman 2 P5-P8 (500), halaman 3 P9-P10 (400).
+	createdAt := func(i int) int64 {
+		switch {
+		case i < 4:
+			return 1000
+		case i < 8:
+			return 500
+		}
+		return 400
+	}
+	tests := []struct {
+		name     string
+		maxPosts int
+		maxPages int
+		limit    int64
+		medias   int
+		requests int
+	}{
+		{"sampai more_available=false", 0, 10, 0, 10, 3},
+		{"max_pages", 0, 2, 0, 8, 2},
+		{"max_posts dicek per halaman", 5, 10, 0, 8, 2},
+		{"max_posts pas satu halaman", 4, 10, 0, 4, 1},
+		{"semua di halaman terakhir lebih lama dari limit", 0, 10, 800, 8, 2},
+		{"limit di atas semua postingan", 0, 10, 2000, 4, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, f := newFakeFetcher(t)
+			srv.AddPosts("kopi", split.FeedTop, 4, timedPosts(10, createdAt)...)
+
+			pages := 0
+			f.onPage = func(Page) { pages++ }
+			result := emptyResult()
+			f.paginate("top", result, tt.maxPosts, tt.maxPages, tt.limit)
+
+			if n, _ := countMedias(result.Response.Data.Top.Sections, 0); n != tt.medias {
+				t.Errorf("merged %d medias, want %d", n, tt.medias)
+			}
+			if n := len(srv.Requests()); n != tt.requests {
+				t.Errorf("Instagram got %d requests, want %d", n, tt.requests)
+			}
+			if pages != tt.requests {
+				t.Errorf("onPage called %d times, want %d", pages, tt.requests)
+			}
+			raw := result.Raw["data"].(map[string]interface{})["top"].(map[string]interface{})
+			if n := len(raw["sections"].([]interface{})); n != len(result.Response.Data.Top.Sections) {
+				t.Errorf("Raw has %d sections, Response has %d", n, len(result.Response.Data.Top.Sections))
+			}
+		})
+	}
+}
+
+func TestPaginateStopsOnFailedPage(t *testing.T) {
+	srv, f := newFakeFetcher(t)
+	srv.AddPosts("kopi", split.FeedTop, 4, timedPosts(10, func(int) int64 { return 1000 })...)
+	srv.FailRequest(2, fakeig.FaultServerError)
+
+	result := emptyResult()
+	f.paginate("top", result, 0, 10, 0)
+	if n, _ := countMedias(result.Response.Data.Top.Sections, 0); n != 4 {
+		t.Errorf("merged %d medias, want the 4 from the first page", n)
+	}
+}
+
+func TestMergeSections(t *testing.T) {
+	var first split.FeedSections
+	if err := json.Unmarshal([]byte(`{
+		"more_available": true, "next_max_id": "a", "next_page": 1,
+		"sections": [{"layout_content": {"medias": [{"media": {"code": "P1"}}]}}]
+	}`), &first); err != nil {
+		t.Fatal(err)
+	}
+	result := &Result{
+		Raw: map[string]interface{}{"data": map[string]interface{}{
+			"top": map[string]interface{}{
+				"sections":       []interface{}{"raw1"},
+				"more_available": true,
+				"next_max_id":    "a",
+				"extra":          "kept",
+			},
+		}},
+		Response: &split.TopLevelResponse{},
+	}
+	*result.Response.FeedSections("top") = first
+
+	var next split.FeedSections
+	if err := json.Unmarshal([]byte(`{
+		"more_available": false, "next_max_id": "", "next_page": 2, "next_media_ids": ["9"],
+		"sections": [{"layout_content": {"medias": [{"media": {"code": "P2"}}, {"media": {"code": "P3"}}]}}]
+	}`), &next); err != nil {
+		t.Fatal(err)
+	}
+	mergeSections(result, "top", []interface{}{"raw2"}, next)
+
+	top := result.Response.FeedSections("top")
+	if n, _ := countMedias(top.Sections, 0); n != 3 {
+		t.Errorf("Response has %d medias, want 3", n)
+	}
+	if top.MoreAvailable || top.NextMaxID != "" || top.NextPage != 2 {
+		t.Errorf("Response cursor = %+v, want the cursor of the merged page", top)
+	}
+
+	raw := result.Raw["data"].(map[string]interface{})["top"].(map[string]interface{})
+	if got := fmt.Sprint(raw["sections"]); got != "[raw1 raw2]" {
+		t.Errorf("Raw sections = %s, want [raw1 raw2]", got)
+	}
+	if raw["more_available"] != false || raw["next_max_id"] != "" || raw["next_page"] != 2 {
+		t.Errorf("Raw cursor = %v", raw)
+	}
+	if raw["extra"] != "kept" {
+		t.Errorf("unknown Raw field was dropped: %v", raw)
+	}
+
+	// Feed yang belum ada di Raw dibuat; Raw tanpa "data" dibiarkan.
+	mergeSections(result, "recent", []interface{}{"raw3"}, next)
+	if _, ok := result.Raw["data"].(map[string]interface{})["recent"]; !ok {
+		t.Error("recent feed was not added to Raw")
+	}
+	noData := &Result{Raw: map[string]interface{}{}, Response: &split.TopLevelResponse{}}
+	mergeSections(noData, "top", []interface{}{"raw"}, next)
+	if len(noData.Raw) != 0 {
+		t.Errorf("Raw without data = %v, want it untouched", noData.Raw)
+	}
+	if n, _ := countMedias(noData.Response.Data.Top.Sections, 0); n != 2 {
+		t.Errorf("Response without Raw data has %d medias, want 2", n)
+	}
+}
+
+func TestCountMedias(t *testing.T) {
+	var sections []split.Section
+	if err := json.Unmarshal([]byte(`[
+		{"layout_content": {"medias": [{"media": {"caption": {"created_at": 100}}}, {"media": {"caption": {"created_at": 300}}}]}},
+		{"layout_content": {"fill_items": [{"media": {"caption": {"created_at": 200}}}]}}
+	]`), &sections); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		sections []split.Section
+		limit    int64
+		count    int
+		allOlder bool
+	}{
+		{"tanpa limit", sections, 0, 3, false},
+		{"sebagian lebih lama", sections, 250, 3, false},
+		{"tepat di limit tidak dianggap lebih lama", sections, 300, 3, false},
+		{"semua lebih lama", sections, 301, 3, true},
+		{"halaman kosong", nil, 301, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			count, allOlder := countMedias(tt.sections, tt.limit)
+			if count != tt.count || allOlder != tt.allOlder {
+				t.Errorf("countMedias = (%d, %t), want (%d, %t)", count, allOlder, tt.count, tt.allOlder)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package posts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"instagram-scraper/session"
+)
+
+// ErrProxy berarti request gagal di proxy keluar (proxy tidak bisa dihubungi,
+// menolak autentikasi, atau menolak CONNECT/SOCKS), bukan di Instagram.
+var ErrProxy = errors.New("outbound proxy failed")
+
+// ProxyError membungkus kegagalan proxy. errors.Is(err, ErrProxy) bernilai true.
+type ProxyError struct {
+	Proxy string // host:port proxy, tanpa kredensial
+	Err   error
+}
+
+func (e *ProxyError) Error() string {
+	return fmt.Sprintf("%v %s: %v", ErrProxy, e.Proxy, e.Err)
+}
+
+func (e *ProxyError) Unwrap() error {
+	return e.Err
+}
+
+// Is membuat errors.Is(err, ErrProxy) bernilai true untuk setiap ProxyError.
+func (e *ProxyError) Is(target error) bool {
+	return target == ErrProxy
+}
+
+// defaultProxy adalah proxy untuk sesi yang tidak punya proxy sendiri. Nil berarti
+// http.DefaultTransport (yang tetap menghormati HTTPS_PROXY dari environment).
+var defaultProxy atomic.Pointer[url.URL]
+
+// SetDefaultProxy mengatur proxy keluar untuk sesi tanpa Session.Proxy.
+// raw boleh http://, https:// atau socks5://; string kosong menghapusnya.
+func SetDefaultProxy(raw string) error {
+	u, err := session.ParseProxy(raw)
+	if err != nil {
+		return err
+	}
+	defaultProxy.Store(u)
+	return nil
+}
+
+// proxyFor mengembalikan proxy yang dipakai sess: proxy miliknya, atau proxy default.
+func proxyFor(sess session.Session) (*url.URL, error) {
+	u, err := session.ParseProxy(sess.Proxy)
+	if err != nil || u != nil {
+		return u, err
+	}
+	return defaultProxy.Load(), nil
+}
+
+// sameProxy melaporkan apakah a dan b menunjuk proxy yang sama (nil = tanpa proxy).
+func sameProxy(a, b *url.URL) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.String() == b.String()
+}
+
+// transports menyimpan satu *http.Transport per URL proxy agar koneksi ke proxy
+// yang sama dipakai ulang di antara Fetch.
+var transports sync.Map
+
+// transportFor mengembalikan transport yang mengarahkan semua request lewat proxy.
+// Kegagalan menghubungi proxy dan CONNECT yang ditolak dikembalikan sebagai *ProxyError.
+func transportFor(proxy *url.URL) http.RoundTripper {
+	if proxy == nil {
+		return http.DefaultTransport
+	}
+	key := proxy.String()
+	if t, ok := transports.Load(key); ok {
+		return t.(*http.Transport)
+	}
+
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = http.ProxyURL(proxy)
+	// Dengan proxy, setiap dial transport adalah koneksi ke proxy itu sendiri.
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	t.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dialer.DialContext(ctx, network, addr)
+		if err != nil {
+			return nil, &ProxyError{Proxy: proxy.Host, Err: err}
+		}
+		return conn, nil
+	}
+	t.OnProxyConnectResponse = func(ctx context.Context, _ *url.URL, _ *http.Request, resp *http.Response) error {
+		if resp.StatusCode != http.StatusOK {
+			return &ProxyError{Proxy: proxy.Host, Err: fmt.Errorf("CONNECT answered %s", resp.Status)}
+		}
+		return nil
+	}
+	actual, _ := transports.LoadOrStore(key, t)
+	return actual.(*http.Transport)
+}
+
+// asProxyError mengubah error transport menjadi *ProxyError jika asalnya dari proxy.
+// Handshake SOCKS5 tidak punya hook di net/http, jadi dikenali dari pesannya.
+func asProxyError(err error, proxy *url.URL) (*ProxyError, bool) {
+	if proxy == nil {
+		return nil, false
+	}
+	var proxyErr *ProxyError
+	if errors.As(err, &proxyErr) {
+		return proxyErr, true
+	}
+	var opErr *net.OpError
+	if (errors.As(err, &opErr) && opErr.Op == "proxyconnect") || strings.Contains(err.Error(), "socks connect") {
+		return &ProxyError{Proxy: proxy.Host, Err: err}, true
+	}
+	return nil, false
+}
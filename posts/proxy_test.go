@@ -0,0 +1,153 @@
+package posts
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"instagram-scraper/session"
+)
+
+// proxyFetcher membuat fetcher test tanpa retry dan rate limit yang mengirim
+// request lewat proxy.
+func proxyFetcher(t *testing.T, proxy *url.URL) *fetcher {
+	t.Helper()
+	SetRateLimit(RateLimit{}, RateLimit{})
+	t.Cleanup(func() { SetRateLimit(DefaultRateLimit, DefaultSessionRateLimit) })
+	sess := session.Session{Name: "test", Cookie: "sessionid=x"}
+	return &fetcher{
+		ctx:     context.Background(),
+		client:  &http.Client{Transport: transportFor(proxy)},
+		hashtag: "kopi",
+		proxy:   proxy,
+		pool:    session.Single(sess),
+		session: sess,
+		retry:   RetryPolicy{MaxAttempts: 1}.withDefaults(),
+	}
+}
+
+// deadProxy mengembalikan URL proxy yang alamatnya tidak lagi menerima koneksi.
+func deadProxy(t *testing.T, scheme string) *url.URL {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	return &url.URL{Scheme: scheme, Host: addr}
+}
+
+func TestProxyFor(t *testing.T) {
+	if err := SetDefaultProxy("http://default.local:8080"); err != nil {
+		t.Fatal(err)
+	}
+	defer SetDefaultProxy("")
+
+	tests := []struct {
+		name    string
+		proxy   string
+		want    string
+		wantErr bool
+	}{
+		{"proxy default", "", "http://default.local:8080", false},
+		{"proxy sesi menang", "socks5://sesi.local:1080", "socks5://sesi.local:1080", false},
+		{"proxy sesi tidak valid", "ftp://sesi.local:21", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := proxyFor(session.Session{Name: "a", Proxy: tt.proxy})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("proxyFor() error = %v, wantErr %t", err, tt.wantErr)
+			}
+			if !tt.wantErr && u.String() != tt.want {
+				t.Errorf("proxyFor() = %q, want %q", u, tt.want)
+			}
+		})
+	}
+
+	if err := SetDefaultProxy("ftp://default.local:21"); err == nil {
+		t.Error("SetDefaultProxy(ftp://...) error = nil, want invalid scheme")
+	}
+	SetDefaultProxy("")
+	if u, err := proxyFor(session.Session{Name: "a"}); u != nil || err != nil {
+		t.Errorf("proxyFor() without any proxy = %v, %v; want nil, nil", u, err)
+	}
+}
+
+func TestAttemptDeadProxy(t *testing.T) {
+	for _, scheme := range []string{"http", "socks5"} {
+		t.Run(scheme, func(t *testing.T) {
+			proxy := deadProxy(t, scheme)
+			f := proxyFetcher(t, proxy)
+
+			_, err := f.do(mustRequest(t, "http://instagram.test/api/v1/tags/web_info/?tag_name=kopi"))
+			if !errors.Is(err, ErrProxy) {
+				t.Fatalf("do() error = %v, want ErrProxy", err)
+			}
+			if errors.Is(err, ErrUpstream) {
+				t.Errorf("do() error = %v, must not also be ErrUpstream", err)
+			}
+			var proxyErr *ProxyError
+			if !errors.As(err, &proxyErr) || proxyErr.Proxy != proxy.Host {
+				t.Errorf("do() error = %#v, want *ProxyError for %s", err, proxy.Host)
+			}
+		})
+	}
+}
+
+func TestAttemptProxyRejectsConnect(t *testing.T) {
+	proxySrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusProxyAuthRequired)
+	}))
+	defer proxySrv.Close()
+	proxy, _ := url.Parse(proxySrv.URL)
+	f := proxyFetcher(t, proxy)
+
+	_, err := f.do(mustRequest(t, "https://instagram.test/api/v1/tags/web_info/?tag_name=kopi"))
+	if !errors.Is(err, ErrProxy) {
+		t.Errorf("do() error = %v, want ErrProxy for a refused CONNECT", err)
+	}
+}
+
+func TestAttemptUpstreamErrorThroughProxy(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer upstream.Close()
+
+	// Proxy HTTP sederhana: meneruskan request absolute-URI ke tujuannya.
+	var proxied []string
+	proxySrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.URL.String())
+		out := r.Clone(r.Context())
+		out.RequestURI = ""
+		resp, err := http.DefaultTransport.RoundTrip(out)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		defer resp.Body.Close()
+		w.WriteHeader(resp.StatusCode)
+	}))
+	defer proxySrv.Close()
+	proxy, _ := url.Parse(proxySrv.URL)
+	f := proxyFetcher(t, proxy)
+
+	target := upstream.URL + "/api/v1/tags/web_info/?tag_name=kopi"
+	_, err := f.do(mustRequest(t, target))
+	if errors.Is(err, ErrProxy) {
+		t.Fatalf("do() error = %v, want an Instagram error, not ErrProxy", err)
+	}
+	var statusErr *StatusError
+	if !errors.Is(err, ErrUpstream) || !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("do() error = %v, want ErrUpstream with status 503", err)
+	}
+	if len(proxied) != 1 || proxied[0] != target {
+		t.Errorf("proxy saw %v, want one request for %s", proxied, target)
+	}
+}
@@ -0,0 +1,148 @@
+package posts
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RateLimit adalah batas token bucket untuk request ke Instagram: rata-rata
+// PerMinute request per menit, dengan paling banyak Burst request sekaligus
+// setelah periode diam. PerMinute <= 0 berarti tanpa batas.
+type RateLimit struct {
+	PerMinute float64
+	Burst     int
+}
+
+var (
+	// DefaultRateLimit membatasi semua request ke Instagram dari proses ini.
+	DefaultRateLimit = RateLimit{PerMinute: 60, Burst: 10}
+	// DefaultSessionRateLimit membatasi request per sesi (kredensial) Instagram.
+	DefaultSessionRateLimit = RateLimit{PerMinute: 20, Burst: 3}
+)
+
+// limiters adalah rate limiter seluruh proses: satu bucket global dan satu
+// bucket per sesi, sehingga banyak request /posts yang datang bersamaan antre
+// alih-alih menembakkan puluhan request ke instagram.com sekaligus.
+var limiters atomic.Pointer[rateLimiters]
+
+func init() {
+	SetRateLimit(DefaultRateLimit, DefaultSessionRateLimit)
+}
+
+// SetRateLimit mengganti batas global dan batas per sesi. Biasanya dipanggil
+// sekali saat start; request yang sedang menunggu tetap memakai bucket lama.
+func SetRateLimit(global, perSession RateLimit) {
+	limiters.Store(newRateLimiters(global, perSession))
+}
+
+// rateLimiters memegang bucket global dan bucket per sesi (dibuat saat pertama dipakai).
+type rateLimiters struct {
+	global     *tokenBucket
+	perSession RateLimit
+
+	mu       sync.Mutex
+	sessions map[string]*tokenBucket
+}
+
+func newRateLimiters(global, perSession RateLimit) *rateLimiters {
+	return &rateLimiters{
+		global:     newTokenBucket(global),
+		perSession: perSession,
+		sessions:   make(map[string]*tokenBucket),
+	}
+}
+
+// wait menunggu sampai sesi session dan proses ini sama-sama boleh mengirim
+// satu request, lalu mengembalikan lama menunggu. Error hanya jika ctx dibatalkan.
+func (l *rateLimiters) wait(ctx context.Context, session string) (time.Duration, error) {
+	l.mu.Lock()
+	bucket, ok := l.sessions[session]
+	if !ok {
+		bucket = newTokenBucket(l.perSession)
+		l.sessions[session] = bucket
+	}
+	l.mu.Unlock()
+
+	waited, err := bucket.wait(ctx)
+	if err != nil {
+		return waited, err
+	}
+	globalWait, err := l.global.wait(ctx)
+	if err != nil {
+		// Request batal dikirim, jadi token sesi yang sudah diambil tidak terpakai.
+		bucket.release()
+		return 0, err
+	}
+	return waited + globalWait, nil
+}
+
+// tokenBucket adalah token bucket sederhana. Bucket nil tidak pernah membatasi.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64 // Token per detik
+	burst  float64
+	tokens float64
+	last   time.Time
+	now    func() time.Time // time.Now, atau jam palsu di test
+}
+
+func newTokenBucket(limit RateLimit) *tokenBucket {
+	if limit.PerMinute <= 0 {
+		return nil
+	}
+	burst := float64(limit.Burst)
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{rate: limit.PerMinute / 60, burst: burst, tokens: burst, last: time.Now(), now: time.Now}
+}
+
+// wait mengambil satu token, menunggu sampai token tersebut tersedia jika bucket
+// sedang kosong. Token langsung dipesan saat wait dipanggil, jadi pemanggil yang
+// bersamaan dilayani berurutan. Jika ctx dibatalkan, token dikembalikan.
+func (b *tokenBucket) wait(ctx context.Context) (time.Duration, error) {
+	if b == nil {
+		return 0, nil
+	}
+	b.mu.Lock()
+	now := b.now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+	b.tokens--
+	var delay time.Duration
+	if b.tokens < 0 {
+		delay = time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+	b.mu.Unlock()
+	if delay == 0 {
+		return 0, nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return delay, nil
+	case <-ctx.Done():
+		b.release()
+		return 0, ctx.Err()
+	}
+}
+
+// release mengembalikan satu token yang sudah diambil wait tetapi tidak dipakai.
+func (b *tokenBucket) release() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens++
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+}
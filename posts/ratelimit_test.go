@@ -0,0 +1,115 @@
+package posts
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTokenBucketBurstThenWait(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit RateLimit
+		burst int // Jumlah request yang lolos tanpa menunggu
+	}{
+		{"burst 3", RateLimit{PerMinute: 600, Burst: 3}, 3},
+		{"burst 0 dianggap 1", RateLimit{PerMinute: 600}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Jam palsu yang tidak bergerak, agar delay yang dihitung tidak bergantung
+			// pada kecepatan mesin.
+			b := newTokenBucket(tt.limit)
+			start := time.Now()
+			b.last = start
+			b.now = func() time.Time { return start }
+			for i := 0; i < tt.burst; i++ {
+				if waited, err := b.wait(context.Background()); err != nil || waited != 0 {
+					t.Fatalf("request %d waited %s, %v; want no wait within burst", i+1, waited, err)
+				}
+			}
+			// 600 per menit = satu token setiap 100ms.
+			waited, err := b.wait(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := waited - 100*time.Millisecond; diff < -time.Microsecond || diff > time.Microsecond {
+				t.Errorf("request after burst waited %s, want 100ms", waited)
+			}
+		})
+	}
+}
+
+func TestTokenBucketUnlimited(t *testing.T) {
+	for _, limit := range []RateLimit{{}, {PerMinute: 0, Burst: 5}, {PerMinute: -1}} {
+		b := newTokenBucket(limit)
+		if b != nil {
+			t.Errorf("newTokenBucket(%+v) = %+v, want nil", limit, b)
+		}
+		for i := 0; i < 100; i++ {
+			if waited, err := b.wait(context.Background()); err != nil || waited != 0 {
+				t.Fatalf("nil bucket waited %s, %v", waited, err)
+			}
+		}
+	}
+}
+
+func TestTokenBucketCancelReturnsToken(t *testing.T) {
+	b := newTokenBucket(RateLimit{PerMinute: 6, Burst: 1}) // Satu token setiap 10 detik
+	if _, err := b.wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := b.wait(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("wait() error = %v, want context.DeadlineExceeded", err)
+	}
+	// Token yang dipesan request yang batal dikembalikan, jadi antrean tidak bertambah panjang.
+	b.mu.Lock()
+	tokens := b.tokens
+	b.mu.Unlock()
+	if tokens < -0.01 || tokens > 0.01 {
+		t.Errorf("tokens after cancel = %.3f, want about 0", tokens)
+	}
+}
+
+func TestRateLimitersPerSession(t *testing.T) {
+	l := newRateLimiters(RateLimit{}, RateLimit{PerMinute: 6, Burst: 1})
+	ctx := context.Background()
+	for _, name := range []string{"a", "b", "c"} {
+		if waited, err := l.wait(ctx, name); err != nil || waited != 0 {
+			t.Errorf("first request of session %s waited %s, %v; want no wait", name, waited, err)
+		}
+	}
+	// Sesi a sudah memakai token satu-satunya.
+	short, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if _, err := l.wait(short, "a"); err != context.DeadlineExceeded {
+		t.Errorf("second request of session a: error = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestRateLimitersGlobal(t *testing.T) {
+	l := newRateLimiters(RateLimit{PerMinute: 6, Burst: 2}, RateLimit{PerMinute: 6, Burst: 1})
+	ctx := context.Background()
+	for _, name := range []string{"a", "b"} {
+		if waited, err := l.wait(ctx, name); err != nil || waited != 0 {
+			t.Errorf("request of session %s waited %s, %v; want no wait", name, waited, err)
+		}
+	}
+	short, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if _, err := l.wait(short, "c"); err != context.DeadlineExceeded {
+		t.Errorf("third request: error = %v, want context.DeadlineExceeded (global burst used up)", err)
+	}
+	// Sesi c tidak jadi mengirim request, jadi token sesinya dikembalikan.
+	l.mu.Lock()
+	bucket := l.sessions["c"]
+	l.mu.Unlock()
+	bucket.mu.Lock()
+	tokens := bucket.tokens
+	bucket.mu.Unlock()
+	if tokens < 0.99 {
+		t.Errorf("session c tokens after cancelled global wait = %.3f, want 1", tokens)
+	}
+}
@@ -0,0 +1,101 @@
+package posts
+
+import (
+	"errors"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy mengatur pengulangan request ke Instagram yang gagal sementara
+// (429, 5xx, atau error jaringan). 401/403 tidak pernah diulang karena sesi
+// yang ditolak tidak akan pulih dengan sendirinya.
+//
+// Jeda antar percobaan tumbuh eksponensial dari BaseDelay sampai MaxDelay dengan
+// jitter acak, kecuali Instagram mengirim header Retry-After: jeda tersebut
+// selalu dihormati. Percobaan berhenti setelah MaxAttempts, atau lebih awal jika
+// jeda berikutnya akan melewati MaxElapsed sejak percobaan pertama.
+type RetryPolicy struct {
+	MaxAttempts int           // Jumlah percobaan maksimum, termasuk yang pertama. 0 = default, 1 = tanpa retry
+	MaxElapsed  time.Duration // Batas total waktu semua percobaan dan jedanya. 0 = default
+	BaseDelay   time.Duration // Jeda sebelum retry pertama. 0 = default
+	MaxDelay    time.Duration // Jeda maksimum di antara dua percobaan (tanpa Retry-After). 0 = default
+}
+
+// DefaultRetryPolicy dipakai untuk field RetryPolicy yang bernilai 0.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 4,
+	MaxElapsed:  2 * time.Minute,
+	BaseDelay:   time.Second,
+	MaxDelay:    30 * time.Second,
+}
+
+// withDefaults mengisi field yang bernilai 0 dengan DefaultRetryPolicy.
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = DefaultRetryPolicy.MaxAttempts
+	}
+	if p.MaxElapsed <= 0 {
+		p.MaxElapsed = DefaultRetryPolicy.MaxElapsed
+	}
+	if p.BaseDelay <= 0 {
+		p.BaseDelay = DefaultRetryPolicy.BaseDelay
+	}
+	if p.MaxDelay <= 0 {
+		p.MaxDelay = DefaultRetryPolicy.MaxDelay
+	}
+	return p
+}
+
+// backoff mengembalikan jeda sebelum percobaan ke-(attempt+1): BaseDelay*2^(attempt-1),
+// dibatasi MaxDelay, lalu diacak antara setengah dan seluruh nilainya agar
+// request yang gagal bersamaan tidak mengulang di saat yang sama.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.BaseDelay
+	for i := 1; i < attempt && d < p.MaxDelay; i++ {
+		d *= 2
+	}
+	if d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(d-half)+1))
+}
+
+// retryable melaporkan apakah err layak diulang, beserta alasannya untuk log dan metrik.
+func retryable(err error) (string, bool) {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		switch statusErr.StatusCode {
+		case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+			http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return strconv.Itoa(statusErr.StatusCode), true
+		}
+		return strconv.Itoa(statusErr.StatusCode), false
+	}
+	if errors.Is(err, ErrProxy) {
+		return "proxy", true
+	}
+	if errors.Is(err, ErrUpstream) {
+		return "network", true
+	}
+	return "", false
+}
+
+// parseRetryAfter membaca header Retry-After (detik atau tanggal HTTP). 0 jika tidak ada atau tidak valid.
+func parseRetryAfter(v string, now time.Time) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
@@ -0,0 +1,188 @@
+package posts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"instagram-scraper/session"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in   string
+		want time.Duration
+	}{
+		{"", 0},
+		{"0", 0},
+		{"7", 7 * time.Second},
+		{"-3", 0},
+		{"soon", 0},
+		{now.Add(90 * time.Second).Format(http.TimeFormat), 90 * time.Second},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.in, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestBackoffBounds(t *testing.T) {
+	p := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}.withDefaults()
+	tests := []struct {
+		attempt int
+		max     time.Duration // Jeda sebelum jitter; hasil harus di antara max/2 dan max
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{3, 400 * time.Millisecond},
+		{4, 800 * time.Millisecond},
+		{5, time.Second},
+		{20, time.Second},
+	}
+	for _, tt := range tests {
+		for i := 0; i < 100; i++ {
+			if got := p.backoff(tt.attempt); got < tt.max/2 || got > tt.max {
+				t.Fatalf("backoff(%d) = %s, want between %s and %s", tt.attempt, got, tt.max/2, tt.max)
+			}
+		}
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		err    error
+		reason string
+		want   bool
+	}{
+		{&StatusError{StatusCode: http.StatusTooManyRequests}, "429", true},
+		{&StatusError{StatusCode: http.StatusInternalServerError}, "500", true},
+		{&StatusError{StatusCode: http.StatusBadGateway}, "502", true},
+		{&StatusError{StatusCode: http.StatusServiceUnavailable}, "503", true},
+		{&StatusError{StatusCode: http.StatusGatewayTimeout}, "504", true},
+		{&StatusError{StatusCode: http.StatusUnauthorized}, "401", false},
+		{&StatusError{StatusCode: http.StatusForbidden}, "403", false},
+		{&StatusError{StatusCode: http.StatusNotFound}, "404", false},
+		{fmt.Errorf("%w: connection reset", ErrUpstream), "network", true},
+		{fmt.Errorf("session a: %w", ErrProxy), "proxy", true},
+		{&BlockedError{Kind: ErrLoginRequired, StatusCode: http.StatusUnauthorized}, "", false},
+		{context.Canceled, "", false},
+	}
+	for _, tt := range tests {
+		reason, ok := retryable(tt.err)
+		if reason != tt.reason || ok != tt.want {
+			t.Errorf("retryable(%v) = %q, %t; want %q, %t", tt.err, reason, ok, tt.reason, tt.want)
+		}
+	}
+}
+
+func TestFetcherDoRetries(t *testing.T) {
+	SetRateLimit(RateLimit{}, RateLimit{})
+	defer SetRateLimit(DefaultRateLimit, DefaultSessionRateLimit)
+
+	fast := RetryPolicy{MaxAttempts: 4, MaxElapsed: time.Second, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+	tests := []struct {
+		name       string
+		statuses   []int // Status per request; setelah habis, 200
+		retryAfter string
+		policy     RetryPolicy
+		wantErr    error // Nil jika harus berhasil
+		wantCalls  int32
+	}{
+		{"berhasil langsung", nil, "", fast, nil, 1},
+		{"500 lalu berhasil", []int{500, 503}, "", fast, nil, 3},
+		{"429 lalu berhasil", []int{429}, "", fast, nil, 2},
+		{"500 sampai MaxAttempts", []int{500, 500, 500, 500, 500}, "", fast, ErrUpstream, 4},
+		{"401 tidak diulang", []int{401}, "", fast, ErrUnauthorized, 1},
+		{"403 tidak diulang", []int{403}, "", fast, ErrUnauthorized, 1},
+		{"404 tidak diulang", []int{404}, "", fast, ErrUpstream, 1},
+		// Retry-After yang melewati MaxElapsed langsung menyerah alih-alih menunggu.
+		{"Retry-After melewati MaxElapsed", []int{429, 429}, "60", fast, ErrRateLimited, 1},
+		{"MaxAttempts 1 tanpa retry", []int{500}, "", RetryPolicy{MaxAttempts: 1, BaseDelay: time.Millisecond}, ErrUpstream, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := int(atomic.AddInt32(&calls, 1))
+				if n <= len(tt.statuses) {
+					if tt.retryAfter != "" {
+						w.Header().Set("Retry-After", tt.retryAfter)
+					}
+					w.WriteHeader(tt.statuses[n-1])
+					w.Write([]byte(`{"status":"fail"}`))
+					return
+				}
+				w.Write([]byte(`{"data":{}}`))
+			}))
+			defer srv.Close()
+
+			body, err := newTestFetcher(srv, tt.policy).do(mustRequest(t, srv.URL))
+			if tt.wantErr == nil {
+				if err != nil || string(body) != `{"data":{}}` {
+					t.Errorf("do() = %q, %v; want body", body, err)
+				}
+			} else if !errors.Is(err, tt.wantErr) {
+				t.Errorf("do() error = %v, want %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("server got %d requests, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestFetcherDoMaxElapsed(t *testing.T) {
+	SetRateLimit(RateLimit{}, RateLimit{})
+	defer SetRateLimit(DefaultRateLimit, DefaultSessionRateLimit)
+
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	policy := RetryPolicy{MaxAttempts: 100, MaxElapsed: 200 * time.Millisecond, BaseDelay: 40 * time.Millisecond, MaxDelay: 40 * time.Millisecond}
+	start := time.Now()
+	_, err := newTestFetcher(srv, policy).do(mustRequest(t, srv.URL))
+	if !errors.Is(err, ErrUpstream) {
+		t.Fatalf("do() error = %v, want ErrUpstream", err)
+	}
+	if elapsed := time.Since(start); elapsed > policy.MaxElapsed+100*time.Millisecond {
+		t.Errorf("do() took %s, want at most about %s", elapsed, policy.MaxElapsed)
+	}
+	// Jeda 20-40ms dalam anggaran 200ms: lebih dari satu percobaan, tetapi jauh dari MaxAttempts.
+	if calls < 2 || calls > 11 {
+		t.Errorf("server got %d requests, want between 2 and 11", calls)
+	}
+}
+
+// newTestFetcher membuat fetcher yang mengirim request ke srv dengan satu sesi palsu.
+func newTestFetcher(srv *httptest.Server, policy RetryPolicy) *fetcher {
+	sess := session.Session{Name: "test", Cookie: "sessionid=x"}
+	return &fetcher{
+		ctx:     context.Background(),
+		client:  srv.Client(),
+		hashtag: "kopi",
+		pool:    session.Single(sess),
+		session: sess,
+		retry:   policy.withDefaults(),
+	}
+}
+
+func mustRequest(t *testing.T, url string) *http.Request {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return req
+}
@@ -0,0 +1,42 @@
+package posts
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	"instagram-scraper/session"
+)
+
+// pool adalah pool sesi Instagram yang dipakai Fetch. Nil berarti satu sesi dari
+// environment variable (lihat session.FromEnv).
+var pool atomic.Pointer[session.Pool]
+
+var (
+	envPoolOnce sync.Once
+	envPool     *session.Pool
+)
+
+// SetSessionPool mengganti pool sesi yang dipakai Fetch berikutnya dan
+// mengembalikan pool sebelumnya (nil = sesi dari environment variable), agar
+// bisa dipulihkan lagi, misalnya di akhir test.
+func SetSessionPool(p *session.Pool) *session.Pool {
+	return pool.Swap(p)
+}
+
+// sessionPool mengembalikan pool yang di-set lewat SetSessionPool, atau pool
+// berisi satu sesi dari environment variable.
+func sessionPool() *session.Pool {
+	if p := pool.Load(); p != nil {
+		return p
+	}
+	envPoolOnce.Do(func() { envPool = session.Single(session.FromEnv()) })
+	return envPool
+}
+
+// rejectsSession melaporkan apakah err berarti sesinya ditolak Instagram (401/403,
+// login wall, login_required, atau checkpoint) sehingga sesi perlu dikarantina.
+func rejectsSession(err error) bool {
+	return errors.Is(err, ErrUnauthorized) || errors.Is(err, ErrLoginWall) ||
+		errors.Is(err, ErrLoginRequired) || errors.Is(err, ErrCheckpoint)
+}
@@ -0,0 +1,97 @@
+package posts
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"instagram-scraper/session"
+)
+
+func TestSetSessionPoolReturnsPrevious(t *testing.T) {
+	a := session.Single(session.Session{Name: "a", Cookie: "sessionid=a"})
+	b := session.Single(session.Session{Name: "b", Cookie: "sessionid=b"})
+	orig := SetSessionPool(a)
+	defer SetSessionPool(orig)
+
+	if prev := SetSessionPool(b); prev != a {
+		t.Errorf("SetSessionPool(b) returned %p, want a (%p)", prev, a)
+	}
+	if prev := SetSessionPool(nil); prev != b {
+		t.Errorf("SetSessionPool(nil) returned %p, want b (%p)", prev, b)
+	}
+	// Nil kembali ke sesi dari environment variable.
+	if sessionPool() == nil || sessionPool() == b {
+		t.Error("sessionPool() after SetSessionPool(nil) did not fall back to the environment pool")
+	}
+}
+
+func TestFetcherDoFailsOverToNextSession(t *testing.T) {
+	SetRateLimit(RateLimit{}, RateLimit{})
+	defer SetRateLimit(DefaultRateLimit, DefaultSessionRateLimit)
+
+	var cookies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cookies = append(cookies, r.Header.Get("Cookie"))
+		if r.Header.Get("Cookie") == "sessionid=a" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	pool, err := session.NewPool(session.Config{Sessions: []session.Session{
+		{Name: "a", Cookie: "sessionid=a"},
+		{Name: "b", Cookie: "sessionid=b"},
+		{Name: "c", Cookie: "sessionid=c"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := newTestFetcher(srv, RetryPolicy{MaxAttempts: 1})
+	f.pool = pool
+	f.session, _ = pool.Acquire()
+	req := mustRequest(t, srv.URL)
+	setHeaders(req, f.hashtag, f.session)
+
+	if _, err := f.do(req); err != nil {
+		t.Fatalf("do() error = %v, want success with session b", err)
+	}
+	if want := []string{"sessionid=a", "sessionid=b"}; !reflect.DeepEqual(cookies, want) {
+		t.Errorf("cookies sent = %v, want %v", cookies, want)
+	}
+	if f.session.Name != "b" {
+		t.Errorf("fetcher session = %q, want b for the following pages", f.session.Name)
+	}
+	health := pool.Health()
+	if health.Sessions[0].QuarantinedUntil == 0 || health.Healthy != 2 {
+		t.Errorf("pool health = %+v, want only a quarantined", health)
+	}
+}
+
+func TestFetcherDoSingleSessionDoesNotFailOver(t *testing.T) {
+	SetRateLimit(RateLimit{}, RateLimit{})
+	defer SetRateLimit(DefaultRateLimit, DefaultSessionRateLimit)
+
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	f := newTestFetcher(srv, RetryPolicy{MaxAttempts: 1})
+	if _, err := f.do(mustRequest(t, srv.URL)); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("do() error = %v, want ErrUnauthorized", err)
+	}
+	if calls != 1 {
+		t.Errorf("server got %d requests, want 1", calls)
+	}
+	// Sesi tunggal tidak dikarantina, jadi request berikutnya masih bisa mencoba.
+	if _, err := f.pool.Acquire(); err != nil {
+		t.Errorf("Acquire() after rejection error = %v, want the single session", err)
+	}
+}
@@ -0,0 +1,114 @@
+package scraper
+
+import (
+	"context"
+	"sync"
+
+	"instagram-scraper/split"
+)
+
+// flightGroup menggabungkan scraping yang identik dan sedang berjalan bersamaan
+// menjadi satu panggilan ke Instagram (mirip singleflight). Berbeda dengan
+// singleflight biasa, panggilan bersama dijalankan dengan context sendiri yang
+// baru dibatalkan ketika SEMUA pemanggil yang menunggunya sudah pergi, jadi satu
+// klien yang disconnect tidak menggagalkan klien lain.
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall
+}
+
+type flightCall struct {
+	done    chan struct{}
+	posts   []split.Post
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// do menjalankan fn untuk key, atau menunggu hasil fn yang sudah berjalan untuk
+// key yang sama. shared bernilai true jika hasilnya berasal dari panggilan pemanggil lain.
+func (g *flightGroup) do(ctx context.Context, key string, fn func(ctx context.Context) ([]split.Post, error)) (posts []split.Post, shared bool, err error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*flightCall)
+	}
+	c, ok := g.calls[key]
+	if ok {
+		c.waiters++
+	} else {
+		callCtx, cancel := context.WithCancel(context.Background())
+		c = &flightCall{done: make(chan struct{}), waiters: 1, cancel: cancel}
+		g.calls[key] = c
+		go func() {
+			defer cancel()
+			c.posts, c.err = fn(callCtx)
+			g.mu.Lock()
+			// Entri mungkin sudah dihapus (semua pemanggil pergi) dan diganti panggilan baru.
+			if g.calls[key] == c {
+				delete(g.calls, key)
+			}
+			g.mu.Unlock()
+			close(c.done)
+		}()
+	}
+	g.mu.Unlock()
+
+	select {
+	case <-c.done:
+		if c.err != nil {
+			return nil, ok, c.err
+		}
+		// Salin slice agar pemanggil yang berbeda tidak saling mengubah hasil.
+		return append([]split.Post(nil), c.posts...), ok, nil
+	case <-ctx.Done():
+		g.mu.Lock()
+		c.waiters--
+		if c.waiters == 0 {
+			// Lepas dari g.calls sekarang juga, agar request baru untuk key yang sama
+			// memulai panggilan sendiri alih-alih bergabung ke panggilan yang sudah dibatalkan.
+			c.cancel()
+			if g.calls[key] == c {
+				delete(g.calls, key)
+			}
+		}
+		g.mu.Unlock()
+		return nil, ok, ctx.Err()
+	}
+}
+
+// keyedMutex memberi satu mutex per key (misalnya per hashtag).
+type keyedMutex struct {
+	mu    sync.Mutex
+	locks map[string]*keyedLock
+}
+
+type keyedLock struct {
+	sync.Mutex
+	refs int
+}
+
+// lock mengunci key dan mengembalikan fungsi untuk membukanya.
+func (k *keyedMutex) lock(key string) (unlock func()) {
+	k.mu.Lock()
+	if k.locks == nil {
+		k.locks = make(map[string]*keyedLock)
+	}
+	l, ok := k.locks[key]
+	if !ok {
+		l = &keyedLock{}
+		k.locks[key] = l
+	}
+	l.refs++
+	k.mu.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+		k.mu.Lock()
+		l.refs--
+		if l.refs == 0 {
+			delete(k.locks, key)
+		}
+		k.mu.Unlock()
+	}
+}
@@ -0,0 +1,117 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"instagram-scraper/split"
+)
+
+func TestFlightGroupSharesConcurrentCalls(t *testing.T) {
+	var g flightGroup
+	var calls int32
+	release := make(chan struct{})
+	fn := func(ctx context.Context) ([]split.Post, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return []split.Post{{Code: "A"}}, nil
+	}
+
+	type result struct {
+		posts  []split.Post
+		shared bool
+		err    error
+	}
+	results := make(chan result, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			posts, shared, err := g.do(context.Background(), "kopi", fn)
+			results <- result{posts, shared, err}
+		}()
+	}
+	waitFor(t, func() bool {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		c := g.calls["kopi"]
+		return c != nil && c.waiters == 2
+	})
+	close(release)
+
+	sharedCount := 0
+	for i := 0; i < 2; i++ {
+		r := <-results
+		if r.err != nil || len(r.posts) != 1 {
+			t.Fatalf("do() = %v, %v; want one post", r.posts, r.err)
+		}
+		if r.shared {
+			sharedCount++
+		}
+	}
+	if calls != 1 || sharedCount != 1 {
+		t.Errorf("fn called %d times, %d shared results; want 1 and 1", calls, sharedCount)
+	}
+}
+
+// Request baru yang datang setelah semua pemanggil pergi, tetapi sebelum fn yang
+// dibatalkan selesai, harus memulai panggilan baru alih-alih mendapat context.Canceled.
+func TestFlightGroupNewCallAfterAllWaitersLeft(t *testing.T) {
+	var g flightGroup
+	firstCancelled := make(chan struct{})
+	releaseFirst := make(chan struct{})
+	var calls int32
+	fn := func(ctx context.Context) ([]split.Post, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			<-ctx.Done()
+			close(firstCancelled)
+			<-releaseFirst // fn lambat berhenti setelah dibatalkan
+			return nil, ctx.Err()
+		}
+		return []split.Post{{Code: "B"}}, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, _, err := g.do(ctx, "kopi", fn)
+		done <- err
+	}()
+	waitFor(t, func() bool { return atomic.LoadInt32(&calls) == 1 })
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("first do() error = %v, want context.Canceled", err)
+	}
+	<-firstCancelled
+
+	// Tanpa perbaikan, do() bergabung ke panggilan yang dibatalkan dan menunggu sampai timeout.
+	ctx2, cancel2 := context.WithTimeout(context.Background(), time.Second)
+	defer cancel2()
+	posts, shared, err := g.do(ctx2, "kopi", fn)
+	close(releaseFirst)
+	if err != nil {
+		t.Fatalf("second do() error = %v, want nil", err)
+	}
+	if shared || len(posts) != 1 || posts[0].Code != "B" {
+		t.Errorf("second do() = %v (shared %t), want a fresh call returning B", posts, shared)
+	}
+
+	// Panggilan pertama yang selesai belakangan tidak boleh menghapus entri lain.
+	waitFor(t, func() bool {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		return len(g.calls) == 0
+	})
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met within 2s")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
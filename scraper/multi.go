@@ -0,0 +1,130 @@
+package scraper
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"instagram-scraper/split"
+	"instagram-scraper/tagname"
+)
+
+// DefaultConcurrency adalah jumlah hashtag yang di-scrape bersamaan oleh ScrapeMany jika concurrency < 1.
+const DefaultConcurrency = 4
+
+// MultiResult adalah hasil ScrapeMany.
+type MultiResult struct {
+	// Posts adalah gabungan postingan semua hashtag tanpa duplikat (berdasarkan
+	// shortcode), sesuai urutan hashtag lalu urutan kemunculan. Post.Hashtags
+	// berisi setiap hashtag yang memunculkan postingan tersebut.
+	Posts []split.Post
+	// PerTag adalah postingan setiap hashtag sebelum digabung.
+	PerTag map[string][]split.Post
+	// Counts adalah jumlah postingan per hashtag sebelum deduplikasi.
+	Counts map[string]int
+	// Errors berisi error hashtag yang gagal. Hashtag yang gagal tidak ada di PerTag/Counts.
+	Errors map[string]error
+}
+
+// ScrapeMany menjalankan Scrape untuk setiap hashtag dengan paling banyak
+// concurrency hashtag sekaligus, lalu menggabungkan hasilnya. Kegagalan satu
+// hashtag dicatat di MultiResult.Errors tanpa menghentikan yang lain; error
+// hanya dikembalikan jika semua hashtag gagal (error hashtag pertama) atau ctx dibatalkan.
+// Hashtag dinormalkan dengan tagname.Normalize, dan key di MultiResult memakai
+// bentuk normalnya (hashtag yang tidak valid memakai nilai aslinya di Errors).
+func ScrapeMany(ctx context.Context, hashtags []string, opts Options, concurrency int) (*MultiResult, error) {
+	if concurrency < 1 {
+		concurrency = DefaultConcurrency
+	}
+
+	type outcome struct {
+		posts []split.Post
+		err   error
+	}
+	outcomes := make([]outcome, len(hashtags))
+	normalized := make([]string, len(hashtags))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, hashtag := range hashtags {
+		var err error
+		if normalized[i], err = tagname.Normalize(hashtag); err != nil {
+			normalized[i], outcomes[i].err = hashtag, err
+			continue
+		}
+		hashtag = normalized[i]
+		wg.Add(1)
+		go func(i int, hashtag string) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				outcomes[i].err = ctx.Err()
+				return
+			}
+			defer func() { <-sem }()
+			outcomes[i].posts, outcomes[i].err = Scrape(ctx, hashtag, opts)
+		}(i, hashtag)
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	result := &MultiResult{
+		PerTag: make(map[string][]split.Post),
+		Counts: make(map[string]int),
+		Errors: make(map[string]error),
+	}
+	var tagged [][]split.Post
+	var tags []string
+	for i, hashtag := range normalized {
+		if err := outcomes[i].err; err != nil {
+			log.Printf("Error scraping hashtag '%s': %v", hashtag, err)
+			result.Errors[hashtag] = err
+			continue
+		}
+		result.PerTag[hashtag] = outcomes[i].posts
+		result.Counts[hashtag] = len(outcomes[i].posts)
+		tagged = append(tagged, outcomes[i].posts)
+		tags = append(tags, hashtag)
+	}
+	if len(result.Errors) == len(hashtags) {
+		return nil, outcomes[0].err
+	}
+	result.Posts = Merge(tags, tagged)
+	log.Printf("Merged %d unique posts from %d hashtags (%d failed).", len(result.Posts), len(tags), len(result.Errors))
+	return result, nil
+}
+
+// Merge menggabungkan postingan beberapa hashtag (perTag[i] milik tags[i]) dan
+// membuang duplikat berdasarkan shortcode. Postingan tanpa shortcode tidak bisa
+// dicocokkan, jadi selalu dianggap unik. Slice input tidak diubah.
+func Merge(tags []string, perTag [][]split.Post) []split.Post {
+	merged := make([]split.Post, 0)
+	index := make(map[string]int) // shortcode -> posisi di merged
+	for i, posts := range perTag {
+		for _, post := range posts {
+			if j, ok := index[post.Code]; ok && post.Code != "" {
+				if !containsString(merged[j].Hashtags, tags[i]) {
+					merged[j].Hashtags = append(merged[j].Hashtags, tags[i])
+				}
+				continue
+			}
+			post.Hashtags = append(append([]string(nil), post.Hashtags...), tags[i])
+			if post.Code != "" {
+				index[post.Code] = len(merged)
+			}
+			merged = append(merged, post)
+		}
+	}
+	return merged
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,231 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"instagram-scraper/fakeig"
+	"instagram-scraper/posts"
+	"instagram-scraper/session"
+	"instagram-scraper/split"
+)
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name   string
+		tags   []string
+		perTag [][]split.Post
+		want   []split.Post
+	}{
+		{
+			name:   "kosong",
+			tags:   nil,
+			perTag: nil,
+			want:   []split.Post{},
+		},
+		{
+			name:   "shortcode sama di dua hashtag menjadi satu postingan",
+			tags:   []string{"kopi", "teh"},
+			perTag: [][]split.Post{{{Code: "A"}, {Code: "B"}}, {{Code: "B"}, {Code: "C"}}},
+			want: []split.Post{
+				{Code: "A", Hashtags: []string{"kopi"}},
+				{Code: "B", Hashtags: []string{"kopi", "teh"}},
+				{Code: "C", Hashtags: []string{"teh"}},
+			},
+		},
+		{
+			name:   "urutan hashtag lalu urutan kemunculan",
+			tags:   []string{"teh", "kopi"},
+			perTag: [][]split.Post{{{Code: "C"}, {Code: "A"}}, {{Code: "B"}, {Code: "A"}}},
+			want: []split.Post{
+				{Code: "C", Hashtags: []string{"teh"}},
+				{Code: "A", Hashtags: []string{"teh", "kopi"}},
+				{Code: "B", Hashtags: []string{"kopi"}},
+			},
+		},
+		{
+			name:   "duplikat di hashtag yang sama tidak menambah hashtag",
+			tags:   []string{"kopi"},
+			perTag: [][]split.Post{{{Code: "A"}, {Code: "A"}}},
+			want:   []split.Post{{Code: "A", Hashtags: []string{"kopi"}}},
+		},
+		{
+			name:   "tanpa shortcode selalu unik",
+			tags:   []string{"kopi", "teh"},
+			perTag: [][]split.Post{{{Text: "x"}}, {{Text: "x"}}},
+			want: []split.Post{
+				{Text: "x", Hashtags: []string{"kopi"}},
+				{Text: "x", Hashtags: []string{"teh"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Merge(tt.tags, tt.perTag); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Merge() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeDoesNotModifyInput(t *testing.T) {
+	perTag := [][]split.Post{{{Code: "A", Hashtags: []string{"lama"}}}, {{Code: "A"}}}
+	Merge([]string{"kopi", "teh"}, perTag)
+	if got := perTag[0][0].Hashtags; !reflect.DeepEqual(got, []string{"lama"}) {
+		t.Errorf("input Hashtags = %v, want [lama]", got)
+	}
+}
+
+// newFakeInstagram menjalankan fakeig dan mengarahkan posts ke sana tanpa rate
+// limit dan dengan satu sesi palsu.
+func newFakeInstagram(t *testing.T) *fakeig.Server {
+	t.Helper()
+	srv := fakeig.New()
+	t.Cleanup(srv.Close)
+	if err := posts.SetBaseURL(srv.URL); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { posts.SetBaseURL("") })
+	posts.SetRateLimit(posts.RateLimit{}, posts.RateLimit{})
+	t.Cleanup(func() { posts.SetRateLimit(posts.DefaultRateLimit, posts.DefaultSessionRateLimit) })
+	oldPool := posts.SetSessionPool(session.Single(session.Session{Name: "test", Cookie: "sessionid=x"}))
+	t.Cleanup(func() { posts.SetSessionPool(oldPool) })
+	return srv
+}
+
+// tagPosts membuat postingan dengan kode yang diberikan.
+func tagPosts(codes ...string) []fakeig.Post {
+	out := make([]fakeig.Post, len(codes))
+	for i, code := range codes {
+		out[i] = fakeig.Post{Code: code, Username: "budi", CreatedAt: time.Now().Unix()}
+	}
+	return out
+}
+
+// multiOpts adalah opsi ScrapeMany untuk test: tanpa retry dan tanpa filter waktu.
+var multiOpts = Options{Options: posts.Options{Retry: posts.RetryPolicy{MaxAttempts: 1}}}
+
+func TestScrapeMany(t *testing.T) {
+	srv := newFakeInstagram(t)
+	srv.AddPosts("kopi", split.FeedTop, 2, tagPosts("A", "B", "C")...)
+	srv.AddPosts("teh", split.FeedTop, 0, tagPosts("C", "D")...)
+
+	result, err := ScrapeMany(context.Background(), []string{"#Kopi", "teh"}, multiOpts, 2)
+	if err != nil {
+		t.Fatalf("ScrapeMany() error = %v", err)
+	}
+	if want := map[string]int{"kopi": 3, "teh": 2}; !reflect.DeepEqual(result.Counts, want) {
+		t.Errorf("Counts = %v, want %v", result.Counts, want)
+	}
+	if len(result.PerTag["kopi"]) != 3 || len(result.PerTag["teh"]) != 2 {
+		t.Errorf("PerTag = %v", result.PerTag)
+	}
+	if len(result.Errors) != 0 {
+		t.Errorf("Errors = %v, want none", result.Errors)
+	}
+	var codes []string
+	for _, post := range result.Posts {
+		codes = append(codes, post.Code)
+	}
+	if want := []string{"A", "B", "C", "D"}; !reflect.DeepEqual(codes, want) {
+		t.Errorf("merged codes = %v, want %v", codes, want)
+	}
+	if got := result.Posts[2].Hashtags; !reflect.DeepEqual(got, []string{"kopi", "teh"}) {
+		t.Errorf("Hashtags of C = %v, want [kopi teh]", got)
+	}
+}
+
+func TestScrapeManyPartialFailure(t *testing.T) {
+	srv := newFakeInstagram(t)
+	srv.AddPosts("kopi", split.FeedTop, 0, tagPosts("A", "B")...)
+	srv.AddPosts("teh", split.FeedTop, 0, tagPosts("C")...)
+	srv.FailHashtag("teh", fakeig.FaultUnauthorized)
+
+	result, err := ScrapeMany(context.Background(), []string{"kopi", "teh", "bukan tag"}, multiOpts, 3)
+	if err != nil {
+		t.Fatalf("ScrapeMany() error = %v", err)
+	}
+	if want := map[string]int{"kopi": 2}; !reflect.DeepEqual(result.Counts, want) {
+		t.Errorf("Counts = %v, want %v", result.Counts, want)
+	}
+	if len(result.Errors) != 2 {
+		t.Fatalf("Errors = %v, want teh and the invalid tag", result.Errors)
+	}
+	if !errors.Is(result.Errors["teh"], posts.ErrUnauthorized) {
+		t.Errorf("Errors[teh] = %v, want ErrUnauthorized", result.Errors["teh"])
+	}
+	if _, ok := result.Errors["bukan tag"]; !ok {
+		t.Errorf("invalid hashtag missing from Errors: %v", result.Errors)
+	}
+	if len(result.Posts) != 2 {
+		t.Errorf("merged %d posts, want 2", len(result.Posts))
+	}
+}
+
+func TestScrapeManyAllFail(t *testing.T) {
+	srv := newFakeInstagram(t)
+	srv.FailHashtag("kopi", fakeig.FaultUnauthorized)
+	srv.FailHashtag("teh", fakeig.FaultServerError)
+
+	result, err := ScrapeMany(context.Background(), []string{"kopi", "teh"}, multiOpts, 2)
+	if result != nil {
+		t.Errorf("ScrapeMany() result = %+v, want nil", result)
+	}
+	// Error hashtag pertama yang dikembalikan.
+	if !errors.Is(err, posts.ErrUnauthorized) {
+		t.Errorf("ScrapeMany() error = %v, want ErrUnauthorized from kopi", err)
+	}
+}
+
+func TestScrapeManyConcurrencyBound(t *testing.T) {
+	srv := newFakeInstagram(t)
+	tags := make([]string, 6)
+	for i := range tags {
+		tags[i] = fmt.Sprintf("tag%d", i)
+		srv.AddPosts(tags[i], split.FeedTop, 0, tagPosts(fmt.Sprintf("P%d", i))...)
+	}
+
+	// Proxy di depan fakeig mencatat jumlah request yang berjalan bersamaan.
+	target, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	var inFlight, peak int32
+	front := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(30 * time.Millisecond)
+		proxy.ServeHTTP(w, r)
+	}))
+	defer front.Close()
+	if err := posts.SetBaseURL(front.URL); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := ScrapeMany(context.Background(), tags, multiOpts, 2)
+	if err != nil {
+		t.Fatalf("ScrapeMany() error = %v", err)
+	}
+	if len(result.Posts) != len(tags) {
+		t.Errorf("merged %d posts, want %d", len(result.Posts), len(tags))
+	}
+	if n := atomic.LoadInt32(&peak); n != 2 {
+		t.Errorf("at most %d requests ran at once, want 2", n)
+	}
+}
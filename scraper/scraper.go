@@ -0,0 +1,170 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"instagram-scraper/posts"
+	"instagram-scraper/split"
+	"instagram-scraper/tagname"
+)
+
+// Options mengatur satu kali scraping. Field dari posts.Options (MaxPages,
+// MaxPosts, Limit, Feed) dipakai untuk paginasi sekaligus filter timestamp.
+type Options struct {
+	posts.Options
+
+	// Sink opsional untuk menyimpan hasil. Nil berarti hasil hanya dikembalikan di memori.
+	Sink Sink
+}
+
+// Sink menerima hasil scraping untuk disimpan, misalnya ke file atau database.
+type Sink interface {
+	Save(hashtag string, result *posts.Result, extracted []split.Post) error
+}
+
+// MultiSink meneruskan hasil ke beberapa sink secara berurutan dan berhenti
+// pada error pertama.
+type MultiSink []Sink
+
+// Save memanggil Save di setiap sink.
+func (m MultiSink) Save(hashtag string, result *posts.Result, extracted []split.Post) error {
+	for _, sink := range m {
+		if err := sink.Save(hashtag, result, extracted); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FileSink menyimpan hasil dengan layout file lama:
+// <Dir>/posts_<hashtag>.json, <Dir>/extracted_posts_<hashtag>.json dan .csv.
+// Dir dibuat jika belum ada.
+// Setiap file ditulis secara atomik, dan penulisan untuk hashtag yang sama
+// diserialisasi agar ketiga file selalu berasal dari satu kali scraping.
+type FileSink struct {
+	Dir string
+}
+
+// fileLocks mengunci penulisan file per hashtag di seluruh proses.
+var fileLocks keyedMutex
+
+// Save menulis respons mentah dan postingan hasil ekstraksi ke Dir.
+func (s FileSink) Save(hashtag string, result *posts.Result, extracted []split.Post) error {
+	hashtag, err := tagname.Normalize(hashtag)
+	if err != nil {
+		return err
+	}
+	unlock := fileLocks.lock(filepath.Join(s.Dir, hashtag))
+	defer unlock()
+
+	if err := os.MkdirAll(s.Dir, 0755); err != nil {
+		return fmt.Errorf("creating output directory %s: %w", s.Dir, err)
+	}
+
+	if err := posts.SaveRaw(s.Dir, hashtag, result.Raw); err != nil {
+		return err
+	}
+	return split.WriteFiles(filepath.Join(s.Dir, fmt.Sprintf("extracted_posts_%s", hashtag)), extracted)
+}
+
+// inflight menggabungkan Scrape identik yang berjalan bersamaan di seluruh proses.
+var inflight flightGroup
+
+// Scrape mengambil postingan untuk hashtag dari Instagram (mengikuti paginasi),
+// mengekstrak postingan yang dibuat pada atau setelah opts.Limit, lalu
+// mengembalikannya tanpa melewati file. Jika opts.Sink di-set, hasilnya juga
+// diteruskan ke sink; kegagalan sink hanya dicatat di log, karena postingan
+// yang sudah diambil tetap valid.
+//
+// Panggilan bersamaan untuk hashtag dan opsi yang sama berbagi satu request ke
+// Instagram (Sink yang dipakai adalah milik pemanggil pertama). Slice yang
+// dikembalikan selalu milik pemanggil sendiri.
+//
+// hashtag dinormalkan dengan tagname.Normalize sebelum dipakai, sehingga "#Kopi"
+// dan "kopi" berbagi request, file output, dan kunci di database yang sama.
+// Hashtag yang tidak valid menghasilkan error yang membungkus tagname.ErrInvalid.
+func Scrape(ctx context.Context, hashtag string, opts Options) ([]split.Post, error) {
+	hashtag, err := tagname.Normalize(hashtag)
+	if err != nil {
+		return nil, err
+	}
+	key := fmt.Sprintf("%s|%s|%d|%d|%d", hashtag, opts.Feed, opts.MaxPages, opts.MaxPosts, opts.Limit)
+	extracted, shared, err := inflight.do(ctx, key, func(ctx context.Context) ([]split.Post, error) {
+		return scrape(ctx, hashtag, opts)
+	})
+	if shared {
+		log.Printf("Shared in-flight scrape for hashtag '%s' with a concurrent request.", hashtag)
+	}
+	return extracted, err
+}
+
+// scrape adalah isi Scrape tanpa penggabungan request.
+func scrape(ctx context.Context, hashtag string, opts Options) ([]split.Post, error) {
+	return Stream(ctx, hashtag, opts, nil, nil)
+}
+
+// Progress dilaporkan oleh Stream setiap kali satu halaman selesai diproses.
+type Progress struct {
+	Feed  string `json:"feed"`  // "top" atau "recent"
+	Page  int    `json:"page"`  // Nomor halaman di feed tersebut
+	Posts int    `json:"posts"` // Jumlah postingan yang sudah diekstrak sejauh ini (semua feed)
+}
+
+// Stream sama seperti Scrape, tetapi setiap postingan diteruskan ke onPost
+// begitu halamannya di-decode, dan onProgress dipanggil setelah setiap halaman.
+// Keduanya boleh nil dan dipanggil dari goroutine pemanggil. Stream tidak
+// menggabungkan request bersamaan, karena setiap pemanggil butuh callback-nya sendiri.
+// Pembatalan ctx (misalnya klien disconnect) menghentikan request ke Instagram.
+// Seperti Scrape, hashtag dinormalkan terlebih dahulu. Jika MaxPosts di-set,
+// hasilnya (dan postingan yang diteruskan ke onPost) paling banyak MaxPosts.
+func Stream(ctx context.Context, hashtag string, opts Options, onPost func(split.Post), onProgress func(Progress)) ([]split.Post, error) {
+	hashtag, err := tagname.Normalize(hashtag)
+	if err != nil {
+		return nil, err
+	}
+	extractor := split.NewExtractor(opts.Limit)
+	extracted := make([]split.Post, 0)
+	emit := func(found []split.Post) {
+		// Paginasi berhenti per halaman, jadi halaman terakhir bisa melewati
+		// MaxPosts. Sisanya dibuang di sini agar MaxPosts tetap batas atas.
+		if room := opts.MaxPosts - len(extracted); opts.MaxPosts > 0 && len(found) > room {
+			found = found[:room]
+		}
+		for _, post := range found {
+			if onPost != nil {
+				onPost(post)
+			}
+		}
+		extracted = append(extracted, found...)
+	}
+
+	fetchOpts := opts.Options
+	fetchOpts.OnPage = func(page posts.Page) {
+		emit(extractor.Sections(page.Sections))
+		if onProgress != nil {
+			onProgress(Progress{Feed: page.Feed, Page: page.Number, Posts: len(extracted)})
+		}
+	}
+
+	result, err := posts.Fetch(ctx, hashtag, fetchOpts)
+	if err != nil {
+		return nil, err
+	}
+	if result.Response == nil {
+		// Struktur respons tidak dikenal, jadi tidak ada halaman yang diteruskan
+		// selama Fetch. Ekstrak semuanya sekaligus dengan fallback rekursif.
+		emit(split.ExtractRaw(result.Raw, opts.Limit))
+	}
+	log.Printf("Finished filtering. %d posts extracted for hashtag '%s'.", len(extracted), hashtag)
+
+	if opts.Sink != nil {
+		if err := opts.Sink.Save(hashtag, result, extracted); err != nil {
+			log.Printf("ERROR: Could not save results for hashtag '%s' (returning them anyway): %v", hashtag, err)
+		}
+	}
+	return extracted, nil
+}
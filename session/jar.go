@@ -0,0 +1,104 @@
+package session
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// wwwClaimHeader adalah header respons tempat Instagram mengirim nilai X-IG-WWW-Claim yang baru.
+const wwwClaimHeader = "X-Ig-Set-Www-Claim"
+
+// absorb memperbarui s dari respons Instagram seperti cookie jar browser:
+// Set-Cookie mengganti atau menghapus cookie di s.Cookie (csrftoken juga
+// memperbarui s.CSRFToken), dan header x-ig-set-www-claim mengganti s.WWWClaim.
+// Hasilnya true jika ada yang berubah.
+func (s *Session) absorb(header http.Header, setCookies []*http.Cookie, now time.Time) bool {
+	changed := false
+	if len(setCookies) > 0 {
+		pairs := parseCookieHeader(s.Cookie)
+		for _, c := range setCookies {
+			expired := c.MaxAge < 0 || (!c.Expires.IsZero() && c.Expires.Before(now))
+			if expired {
+				if _, ok := pairs.get(c.Name); ok {
+					pairs = pairs.remove(c.Name)
+					changed = true
+				}
+				continue
+			}
+			if old, ok := pairs.get(c.Name); !ok || old != c.Value {
+				pairs = pairs.set(c.Name, c.Value)
+				changed = true
+			}
+			if c.Name == "csrftoken" && s.CSRFToken != c.Value {
+				s.CSRFToken = c.Value
+				changed = true
+			}
+		}
+		s.Cookie = pairs.String()
+	}
+	if claim := header.Get(wwwClaimHeader); claim != "" && claim != s.WWWClaim {
+		s.WWWClaim = claim
+		changed = true
+	}
+	return changed
+}
+
+// cookiePairs adalah isi header Cookie ("a=1; b=2") dengan urutan aslinya.
+type cookiePairs []cookiePair
+
+type cookiePair struct {
+	name, value string
+}
+
+// parseCookieHeader memecah header Cookie. Bagian tanpa '=' disimpan apa adanya sebagai nama.
+func parseCookieHeader(header string) cookiePairs {
+	var pairs cookiePairs
+	for _, part := range strings.Split(header, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, value, _ := strings.Cut(part, "=")
+		pairs = append(pairs, cookiePair{strings.TrimSpace(name), strings.TrimSpace(value)})
+	}
+	return pairs
+}
+
+func (p cookiePairs) get(name string) (string, bool) {
+	for _, c := range p {
+		if c.name == name {
+			return c.value, true
+		}
+	}
+	return "", false
+}
+
+func (p cookiePairs) set(name, value string) cookiePairs {
+	for i, c := range p {
+		if c.name == name {
+			p[i].value = value
+			return p
+		}
+	}
+	return append(p, cookiePair{name, value})
+}
+
+func (p cookiePairs) remove(name string) cookiePairs {
+	out := p[:0]
+	for _, c := range p {
+		if c.name != name {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// String menyusun kembali header Cookie.
+func (p cookiePairs) String() string {
+	parts := make([]string, len(p))
+	for i, c := range p {
+		parts[i] = c.name + "=" + c.value
+	}
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,120 @@
+package session
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestAbsorb(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	base := Session{Name: "a", Cookie: "sessionid=s1; csrftoken=old; ds_user_id=42", CSRFToken: "old", WWWClaim: "hmac.old"}
+	tests := []struct {
+		name        string
+		header      http.Header
+		cookies     []*http.Cookie
+		wantCookie  string
+		wantCSRF    string
+		wantClaim   string
+		wantChanged bool
+	}{
+		{
+			name:       "tanpa perubahan",
+			wantCookie: base.Cookie, wantCSRF: "old", wantClaim: "hmac.old",
+		},
+		{
+			name:       "csrftoken dirotasi",
+			cookies:    []*http.Cookie{{Name: "csrftoken", Value: "new"}},
+			wantCookie: "sessionid=s1; csrftoken=new; ds_user_id=42", wantCSRF: "new", wantClaim: "hmac.old",
+			wantChanged: true,
+		},
+		{
+			name:       "cookie baru ditambahkan di akhir",
+			cookies:    []*http.Cookie{{Name: "rur", Value: "CLN"}},
+			wantCookie: base.Cookie + "; rur=CLN", wantCSRF: "old", wantClaim: "hmac.old",
+			wantChanged: true,
+		},
+		{
+			name:       "nilai sama tidak dianggap berubah",
+			cookies:    []*http.Cookie{{Name: "sessionid", Value: "s1"}},
+			wantCookie: base.Cookie, wantCSRF: "old", wantClaim: "hmac.old",
+		},
+		{
+			name:       "MaxAge negatif menghapus cookie",
+			cookies:    []*http.Cookie{{Name: "ds_user_id", MaxAge: -1}},
+			wantCookie: "sessionid=s1; csrftoken=old", wantCSRF: "old", wantClaim: "hmac.old",
+			wantChanged: true,
+		},
+		{
+			name:       "Expires di masa lalu menghapus cookie",
+			cookies:    []*http.Cookie{{Name: "ds_user_id", Value: "42", Expires: now.Add(-time.Hour)}},
+			wantCookie: "sessionid=s1; csrftoken=old", wantCSRF: "old", wantClaim: "hmac.old",
+			wantChanged: true,
+		},
+		{
+			name:       "menghapus cookie yang tidak ada",
+			cookies:    []*http.Cookie{{Name: "rur", MaxAge: -1}},
+			wantCookie: base.Cookie, wantCSRF: "old", wantClaim: "hmac.old",
+		},
+		{
+			name:       "www-claim baru",
+			header:     http.Header{wwwClaimHeader: []string{"hmac.new"}},
+			wantCookie: base.Cookie, wantCSRF: "old", wantClaim: "hmac.new",
+			wantChanged: true,
+		},
+		{
+			name:       "www-claim sama",
+			header:     http.Header{wwwClaimHeader: []string{"hmac.old"}},
+			wantCookie: base.Cookie, wantCSRF: "old", wantClaim: "hmac.old",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := base
+			header := tt.header
+			if header == nil {
+				header = http.Header{}
+			}
+			changed := s.absorb(header, tt.cookies, now)
+			if changed != tt.wantChanged {
+				t.Errorf("absorb() = %t, want %t", changed, tt.wantChanged)
+			}
+			if s.Cookie != tt.wantCookie || s.CSRFToken != tt.wantCSRF || s.WWWClaim != tt.wantClaim {
+				t.Errorf("session = {Cookie: %q, CSRFToken: %q, WWWClaim: %q}, want {%q, %q, %q}",
+					s.Cookie, s.CSRFToken, s.WWWClaim, tt.wantCookie, tt.wantCSRF, tt.wantClaim)
+			}
+		})
+	}
+}
+
+func TestPoolAbsorb(t *testing.T) {
+	pool, err := NewPool(Config{Sessions: []Session{{Name: "a", Cookie: "sessionid=s1; csrftoken=old"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp := &http.Response{Header: http.Header{
+		"Set-Cookie":   []string{"csrftoken=new; Path=/; Secure"},
+		wwwClaimHeader: []string{"hmac.new"},
+	}}
+	current, _ := pool.Acquire()
+	got := pool.Absorb(current, resp)
+	if got.CSRFToken != "new" || got.WWWClaim != "hmac.new" || got.Cookie != "sessionid=s1; csrftoken=new" {
+		t.Errorf("Absorb() = %+v, want refreshed csrftoken and www-claim", got)
+	}
+	// Acquire berikutnya memakai sesi yang sudah diperbarui.
+	if next, _ := pool.Acquire(); next != got {
+		t.Errorf("Acquire() after Absorb = %+v, want %+v", next, got)
+	}
+	if h := pool.Health(); h.Sessions[0].LastRefresh == 0 {
+		t.Error("Health().LastRefresh = 0, want the refresh time")
+	}
+
+	// Sesi yang bukan milik pool hanya diperbarui salinannya.
+	other := Session{Name: "lain", Cookie: "sessionid=x"}
+	if got := pool.Absorb(other, resp); got.CSRFToken != "new" {
+		t.Errorf("Absorb(foreign) = %+v, want refreshed copy", got)
+	}
+	if pool.find("lain") != nil {
+		t.Error("Absorb(foreign) added the session to the pool")
+	}
+}
@@ -0,0 +1,268 @@
+package session
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrNoSession dikembalikan Acquire jika semua sesi sedang dikarantina.
+var ErrNoSession = errors.New("no healthy instagram session available")
+
+// Pool adalah kumpulan sesi yang digilir sesuai Strategy. Sesi yang ditolak
+// Instagram (401/403/checkpoint) dikarantina selama durasi tertentu dan tidak
+// dipilih sampai karantinanya selesai. Pool dengan satu sesi tidak pernah
+// mengkarantina, karena tanpa sesi lain scraping akan berhenti total; kegagalannya
+// tetap tercatat di Health. Aman dipakai dari banyak goroutine.
+type Pool struct {
+	strategy   Strategy
+	quarantine time.Duration
+	now        func() time.Time // time.Now, atau jam palsu di test
+
+	mu        sync.Mutex
+	entries   []*entry
+	next      int    // Posisi berikutnya untuk RoundRobin
+	statePath string // File state sesi (lihat SetStateFile). Kosong = tidak disimpan
+
+	saveMu sync.Mutex // Menyerialisasi penulisan file state
+}
+
+// entry adalah satu sesi beserta status kesehatannya.
+type entry struct {
+	session          Session
+	base             string // fingerprint kredensial dari konfigurasi
+	requests         int
+	failures         int
+	lastUsed         time.Time
+	lastSuccess      time.Time
+	lastFailure      time.Time // Terakhir kali sesi ditolak Instagram
+	lastRefresh      time.Time // Terakhir kali cookie/claim diperbarui dari respons Instagram
+	quarantinedUntil time.Time
+	lastError        string
+}
+
+// NewPool membuat pool dari cfg. Nama sesi harus unik; sesi tanpa nama diberi
+// nama "session-<n>".
+func NewPool(cfg Config) (*Pool, error) {
+	if len(cfg.Sessions) == 0 {
+		return nil, errors.New("no sessions configured")
+	}
+	p := &Pool{strategy: cfg.Strategy, quarantine: DefaultQuarantine, now: time.Now}
+	switch cfg.Strategy {
+	case "":
+		p.strategy = RoundRobin
+	case RoundRobin, LeastRecentlyUsed:
+	default:
+		return nil, fmt.Errorf("unknown strategy %q (use %q or %q)", cfg.Strategy, RoundRobin, LeastRecentlyUsed)
+	}
+	if cfg.Quarantine != "" {
+		d, err := time.ParseDuration(cfg.Quarantine)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("quarantine %q is not a positive duration", cfg.Quarantine)
+		}
+		p.quarantine = d
+	}
+
+	names := make(map[string]bool)
+	for i, s := range cfg.Sessions {
+		if s.Name == "" {
+			s.Name = fmt.Sprintf("session-%d", i+1)
+		}
+		if names[s.Name] {
+			return nil, fmt.Errorf("duplicate session name %q", s.Name)
+		}
+		if s.Cookie == "" {
+			return nil, fmt.Errorf("session %q has no cookie", s.Name)
+		}
+		if _, err := ParseProxy(s.Proxy); err != nil {
+			return nil, fmt.Errorf("session %q: %v", s.Name, err)
+		}
+		names[s.Name] = true
+		p.entries = append(p.entries, &entry{session: s, base: fingerprint(s)})
+	}
+	return p, nil
+}
+
+// Single membuat pool berisi satu sesi, misalnya dari FromEnv. Seperti NewPool
+// dengan satu sesi, sesinya tidak pernah dikarantina.
+func Single(s Session) *Pool {
+	return &Pool{strategy: RoundRobin, now: time.Now, entries: []*entry{{session: s, base: fingerprint(s)}}}
+}
+
+// Absorb menerapkan Set-Cookie dan header x-ig-set-www-claim dari resp ke sesi
+// current di pool, menyimpan state ke file jika ada yang berubah, lalu
+// mengembalikan sesi terbaru untuk request berikutnya. Sesi yang bukan milik
+// pool hanya diperbarui salinannya.
+func (p *Pool) Absorb(current Session, resp *http.Response) Session {
+	name := current.Name
+	now := p.now()
+	p.mu.Lock()
+	e := p.find(name)
+	if e == nil {
+		p.mu.Unlock()
+		current.absorb(resp.Header, resp.Cookies(), now)
+		return current
+	}
+	changed := e.session.absorb(resp.Header, resp.Cookies(), now)
+	if changed {
+		e.lastRefresh = now
+	}
+	s := e.session
+	p.mu.Unlock()
+
+	if changed {
+		log.Printf("Refreshed cookies/www-claim of Instagram session '%s' from response.", name)
+		p.saveState()
+	}
+	return s
+}
+
+// Acquire memilih sesi yang sehat untuk dipakai berikutnya dan mencatat pemakaiannya.
+func (p *Pool) Acquire() (Session, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+
+	var chosen *entry
+	switch p.strategy {
+	case LeastRecentlyUsed:
+		for _, e := range p.entries {
+			if e.available(now) && (chosen == nil || e.lastUsed.Before(chosen.lastUsed)) {
+				chosen = e
+			}
+		}
+	default:
+		for i := 0; i < len(p.entries); i++ {
+			e := p.entries[(p.next+i)%len(p.entries)]
+			if e.available(now) {
+				chosen = e
+				p.next = (p.next + i + 1) % len(p.entries)
+				break
+			}
+		}
+	}
+	if chosen == nil {
+		return Session{}, ErrNoSession
+	}
+	chosen.requests++
+	chosen.lastUsed = now
+	return chosen.session, nil
+}
+
+// ReportSuccess mencatat bahwa sesi name baru saja berhasil dipakai.
+func (p *Pool) ReportSuccess(name string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if e := p.find(name); e != nil {
+		e.lastSuccess = p.now()
+	}
+}
+
+// Quarantine mencatat bahwa sesi name ditolak Instagram karena reason (401/403,
+// login wall, checkpoint, ...), menandainya tidak sehat sampai berhasil lagi, dan
+// berhenti memilihnya selama durasi karantina pool.
+func (p *Pool) Quarantine(name string, reason error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	e := p.find(name)
+	if e == nil {
+		return
+	}
+	now := p.now()
+	e.failures++
+	e.lastFailure = now
+	e.lastError = reason.Error()
+	if p.quarantine == 0 || len(p.entries) == 1 {
+		log.Printf("WARNING: Instagram session '%s' was rejected: %v", name, reason)
+		return
+	}
+	e.quarantinedUntil = now.Add(p.quarantine)
+	log.Printf("WARNING: Quarantining Instagram session '%s' until %s: %v", name, e.quarantinedUntil.Format(time.RFC3339), reason)
+}
+
+// Len mengembalikan jumlah sesi di pool.
+func (p *Pool) Len() int {
+	return len(p.entries)
+}
+
+func (p *Pool) find(name string) *entry {
+	for _, e := range p.entries {
+		if e.session.Name == name {
+			return e
+		}
+	}
+	return nil
+}
+
+// available melaporkan apakah sesi boleh dipilih Acquire (tidak sedang dikarantina).
+func (e *entry) available(now time.Time) bool {
+	return !now.Before(e.quarantinedUntil)
+}
+
+// healthy melaporkan apakah sesi tersedia dan tidak ditolak Instagram sejak terakhir kali berhasil.
+func (e *entry) healthy(now time.Time) bool {
+	return e.available(now) && !e.lastFailure.After(e.lastSuccess)
+}
+
+// Health adalah status satu sesi untuk endpoint admin. Tidak berisi kredensial.
+type Health struct {
+	Name             string `json:"name"`
+	Healthy          bool   `json:"healthy"`                     // Tidak dikarantina dan tidak ditolak sejak terakhir berhasil
+	QuarantinedUntil int64  `json:"quarantined_until,omitempty"` // Unix timestamp
+	Requests         int    `json:"requests"`                    // Berapa kali sesi dipilih
+	Failures         int    `json:"failures"`                    // Berapa kali sesi ditolak Instagram
+	LastUsed         int64  `json:"last_used,omitempty"`
+	LastSuccess      int64  `json:"last_success,omitempty"`
+	LastRefresh      int64  `json:"last_refresh,omitempty"` // Terakhir kali cookie/claim diperbarui Instagram
+	LastError        string `json:"last_error,omitempty"`
+	Proxy            string `json:"proxy,omitempty"` // host:port proxy yang diikat ke sesi, tanpa kredensial
+}
+
+// PoolHealth adalah ringkasan kesehatan pool.
+type PoolHealth struct {
+	Strategy Strategy `json:"strategy"`
+	Healthy  int      `json:"healthy"`
+	Total    int      `json:"total"`
+	Sessions []Health `json:"sessions"`
+}
+
+// Health mengembalikan status setiap sesi di pool.
+func (p *Pool) Health() PoolHealth {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	h := PoolHealth{Strategy: p.strategy, Total: len(p.entries), Sessions: make([]Health, 0, len(p.entries))}
+	for _, e := range p.entries {
+		s := Health{
+			Name:      e.session.Name,
+			Healthy:   e.healthy(now),
+			Requests:  e.requests,
+			Failures:  e.failures,
+			LastError: e.lastError,
+		}
+		if !e.available(now) {
+			s.QuarantinedUntil = e.quarantinedUntil.Unix()
+		}
+		if s.Healthy {
+			h.Healthy++
+		}
+		s.LastUsed = unixOrZero(e.lastUsed)
+		s.LastSuccess = unixOrZero(e.lastSuccess)
+		s.LastRefresh = unixOrZero(e.lastRefresh)
+		if u, err := ParseProxy(e.session.Proxy); err == nil && u != nil {
+			s.Proxy = u.Host
+		}
+		h.Sessions = append(h.Sessions, s)
+	}
+	return h
+}
+
+func unixOrZero(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
@@ -0,0 +1,143 @@
+package session
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeClock adalah jam test yang hanya bergerak lewat advance.
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+// newTestPool membuat pool dengan sesi bernama names dan jam palsu.
+func newTestPool(t *testing.T, strategy Strategy, names ...string) (*Pool, *fakeClock) {
+	t.Helper()
+	cfg := Config{Strategy: strategy, Quarantine: "10m"}
+	for _, name := range names {
+		cfg.Sessions = append(cfg.Sessions, Session{Name: name, Cookie: "sessionid=" + name})
+	}
+	p, err := NewPool(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := &fakeClock{t: time.Unix(1700000000, 0)}
+	p.now = clock.now
+	return p, clock
+}
+
+// acquireNames memanggil Acquire n kali dan mengembalikan nama sesinya. Jam maju
+// satu detik setiap kali, agar LRU punya urutan yang jelas.
+func acquireNames(t *testing.T, p *Pool, clock *fakeClock, n int) []string {
+	t.Helper()
+	var names []string
+	for i := 0; i < n; i++ {
+		s, err := p.Acquire()
+		if err != nil {
+			t.Fatalf("Acquire() #%d error = %v", i+1, err)
+		}
+		names = append(names, s.Name)
+		clock.advance(time.Second)
+	}
+	return names
+}
+
+func equalNames(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestPoolRoundRobin(t *testing.T) {
+	p, clock := newTestPool(t, RoundRobin, "a", "b", "c")
+	if got, want := acquireNames(t, p, clock, 5), []string{"a", "b", "c", "a", "b"}; !equalNames(got, want) {
+		t.Errorf("round robin order = %v, want %v", got, want)
+	}
+
+	// Sesi yang dikarantina dilewati tanpa mengacaukan giliran sesi lain.
+	p.Quarantine("a", errors.New("403"))
+	if got, want := acquireNames(t, p, clock, 4), []string{"c", "b", "c", "b"}; !equalNames(got, want) {
+		t.Errorf("order with a quarantined = %v, want %v", got, want)
+	}
+}
+
+func TestPoolLeastRecentlyUsed(t *testing.T) {
+	p, clock := newTestPool(t, LeastRecentlyUsed, "a", "b", "c")
+	if got, want := acquireNames(t, p, clock, 3), []string{"a", "b", "c"}; !equalNames(got, want) {
+		t.Errorf("first LRU round = %v, want %v", got, want)
+	}
+
+	// b dipakai lagi di luar giliran, jadi sekarang a lalu c yang paling lama tidak dipakai.
+	p.mu.Lock()
+	p.find("b").lastUsed = clock.now()
+	p.mu.Unlock()
+	clock.advance(time.Second)
+	if got, want := acquireNames(t, p, clock, 3), []string{"a", "c", "b"}; !equalNames(got, want) {
+		t.Errorf("LRU order = %v, want %v", got, want)
+	}
+
+	p.Quarantine("a", errors.New("checkpoint"))
+	if got, want := acquireNames(t, p, clock, 2), []string{"c", "b"}; !equalNames(got, want) {
+		t.Errorf("LRU order with a quarantined = %v, want %v", got, want)
+	}
+}
+
+func TestPoolQuarantineExpiry(t *testing.T) {
+	p, clock := newTestPool(t, RoundRobin, "a", "b")
+	p.Quarantine("a", errors.New("403"))
+	p.Quarantine("b", errors.New("403"))
+
+	if _, err := p.Acquire(); !errors.Is(err, ErrNoSession) {
+		t.Fatalf("Acquire() with every session quarantined error = %v, want ErrNoSession", err)
+	}
+	h := p.Health()
+	if h.Healthy != 0 || h.Sessions[0].QuarantinedUntil != clock.now().Add(10*time.Minute).Unix() {
+		t.Errorf("Health() = %+v, want both quarantined for 10m", h)
+	}
+
+	clock.advance(10*time.Minute - time.Second)
+	if _, err := p.Acquire(); !errors.Is(err, ErrNoSession) {
+		t.Errorf("Acquire() just before expiry error = %v, want ErrNoSession", err)
+	}
+
+	clock.advance(time.Second)
+	if _, err := p.Acquire(); err != nil {
+		t.Fatalf("Acquire() after expiry error = %v", err)
+	}
+	// Setelah karantina selesai sesi boleh dipilih lagi, tetapi baru sehat setelah berhasil.
+	h = p.Health()
+	if h.Healthy != 0 || h.Sessions[0].QuarantinedUntil != 0 {
+		t.Errorf("Health() after expiry = %+v, want available but not yet healthy", h)
+	}
+	clock.advance(time.Second)
+	p.ReportSuccess("a")
+	if h := p.Health(); !h.Sessions[0].Healthy || h.Sessions[1].Healthy {
+		t.Errorf("Health() after success of a = %+v, want only a healthy", h)
+	}
+}
+
+func TestPoolSingleSessionNeverQuarantined(t *testing.T) {
+	fromConfig, _ := newTestPool(t, RoundRobin, "a")
+	single := Single(Session{Name: "a", Cookie: "sessionid=a"})
+	for name, p := range map[string]*Pool{"NewPool": fromConfig, "Single": single} {
+		t.Run(name, func(t *testing.T) {
+			p.Quarantine("a", errors.New("403"))
+			if _, err := p.Acquire(); err != nil {
+				t.Errorf("Acquire() after rejection error = %v, want the only session", err)
+			}
+			h := p.Health()
+			s := h.Sessions[0]
+			if s.Healthy || s.Failures != 1 || s.QuarantinedUntil != 0 || s.LastError != "403" {
+				t.Errorf("Health() = %+v, want unhealthy with one failure but not quarantined", s)
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package session
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// ParseProxy memvalidasi URL proxy keluar: http://, https:// atau socks5://,
+// dengan host dan port, opsional dengan user:password. String kosong berarti tanpa proxy (nil).
+func ParseProxy(raw string) (*url.URL, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL: %v", err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("proxy %s: scheme must be http, https or socks5", u.Redacted())
+	}
+	if u.Hostname() == "" || u.Port() == "" {
+		return nil, fmt.Errorf("proxy %s: host and port are required", u.Redacted())
+	}
+	return u, nil
+}
@@ -0,0 +1,83 @@
+// Package session mengelola kredensial (sesi browser) Instagram yang dipakai
+// scraper: satu sesi dari environment variable, atau pool beberapa sesi dari
+// file konfigurasi JSON yang digilir dan dikarantina jika ditolak Instagram.
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Session adalah satu set header sesi browser Instagram.
+type Session struct {
+	Name      string `json:"name"`       // Nama unik untuk log, metrik, dan endpoint admin
+	Cookie    string `json:"cookie"`     // Header Cookie lengkap
+	CSRFToken string `json:"csrftoken"`  // Header X-CSRFToken
+	AppID     string `json:"app_id"`     // Header X-IG-App-ID
+	ASBDID    string `json:"asbd_id"`    // Header X-ASBD-ID
+	WWWClaim  string `json:"www_claim"`  // Header X-IG-WWW-Claim
+	UserAgent string `json:"user_agent"` // Header User-Agent. Kosong = user agent Chrome bawaan
+	// Proxy mengikat sesi ke satu proxy keluar (http://, https:// atau socks5://) agar
+	// sesi ini selalu terlihat dari IP yang sama. Kosong = proxy default server.
+	Proxy string `json:"proxy,omitempty"`
+}
+
+// EnvName adalah nama sesi yang dibaca FromEnv.
+const EnvName = "env"
+
+// FromEnv membaca sesi dari environment variable lama (COOKIE, X_CSRFTOKEN,
+// X_IG_APP_ID, X_ASBD_ID, X_IG_WWW_CLAIM, USER_AGENT).
+func FromEnv() Session {
+	return Session{
+		Name:      EnvName,
+		Cookie:    os.Getenv("COOKIE"),
+		CSRFToken: os.Getenv("X_CSRFTOKEN"),
+		AppID:     os.Getenv("X_IG_APP_ID"),
+		ASBDID:    os.Getenv("X_ASBD_ID"),
+		WWWClaim:  os.Getenv("X_IG_WWW_CLAIM"),
+		UserAgent: os.Getenv("USER_AGENT"),
+	}
+}
+
+// Strategy menentukan sesi mana yang dipakai Pool.Acquire berikutnya.
+type Strategy string
+
+const (
+	RoundRobin        Strategy = "round_robin" // Bergiliran sesuai urutan di file konfigurasi
+	LeastRecentlyUsed Strategy = "lru"         // Sesi yang paling lama tidak dipakai
+)
+
+// DefaultQuarantine adalah lama sesi dikarantina setelah ditolak Instagram.
+const DefaultQuarantine = 30 * time.Minute
+
+// Config adalah isi file konfigurasi pool sesi:
+//
+//	{
+//	  "strategy": "round_robin",
+//	  "quarantine": "30m",
+//	  "sessions": [{"name": "akun1", "cookie": "...", "csrftoken": "...", ...}]
+//	}
+type Config struct {
+	Strategy   Strategy  `json:"strategy"`   // round_robin (default) atau lru
+	Quarantine string    `json:"quarantine"` // Durasi Go, misalnya "30m". Kosong = DefaultQuarantine
+	Sessions   []Session `json:"sessions"`
+}
+
+// Load membaca file konfigurasi di path dan membuat Pool darinya.
+func Load(path string) (*Pool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading session config %s: %w", path, err)
+	}
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parsing session config %s: %w", path, err)
+	}
+	pool, err := NewPool(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("session config %s: %w", path, err)
+	}
+	return pool, nil
+}
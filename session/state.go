@@ -0,0 +1,106 @@
+package session
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+
+	"instagram-scraper/atomicfile"
+)
+
+// stateFile adalah isi file state sesi: cookie, csrftoken dan www-claim terbaru
+// setiap sesi, agar hasil refresh dari Instagram tidak hilang saat server restart.
+type stateFile struct {
+	Sessions map[string]savedSession `json:"sessions"`
+}
+
+// savedSession adalah state terakhir satu sesi.
+type savedSession struct {
+	// Base adalah sidik jari kredensial dari konfigurasi saat state ini disimpan.
+	// Jika konfigurasinya diganti (misalnya cookie baru dari DevTools), state lama diabaikan.
+	Base      string `json:"base"`
+	Cookie    string `json:"cookie"`
+	CSRFToken string `json:"csrftoken"`
+	WWWClaim  string `json:"www_claim"`
+	UpdatedAt int64  `json:"updated_at"`
+}
+
+// fingerprint mengembalikan sidik jari kredensial s, tanpa menyimpan kredensialnya sendiri.
+func fingerprint(s Session) string {
+	sum := sha256.Sum256([]byte(s.Cookie + "\x00" + s.CSRFToken + "\x00" + s.WWWClaim))
+	return hex.EncodeToString(sum[:8])
+}
+
+// SetStateFile mengaktifkan penyimpanan state sesi di path. State yang sudah ada
+// di path dipakai menggantikan kredensial dari konfigurasi, kecuali untuk sesi
+// yang kredensial konfigurasinya sudah berubah sejak state disimpan. File ditulis
+// dengan mode 0600 karena berisi cookie.
+func (p *Pool) SetStateFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("reading session state %s: %w", path, err)
+	}
+	var state stateFile
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &state); err != nil {
+			return fmt.Errorf("parsing session state %s: %w", path, err)
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.statePath = path
+	for _, e := range p.entries {
+		saved, ok := state.Sessions[e.session.Name]
+		if !ok {
+			continue
+		}
+		if saved.Base != e.base {
+			log.Printf("Ignoring saved state of Instagram session '%s': its configured credentials changed.", e.session.Name)
+			continue
+		}
+		e.session.Cookie = saved.Cookie
+		e.session.CSRFToken = saved.CSRFToken
+		e.session.WWWClaim = saved.WWWClaim
+		log.Printf("Restored refreshed state of Instagram session '%s' from '%s'.", e.session.Name, path)
+	}
+	return nil
+}
+
+// saveState menulis state semua sesi ke p.statePath. Dipanggil tanpa p.mu terkunci.
+func (p *Pool) saveState() {
+	p.saveMu.Lock()
+	defer p.saveMu.Unlock()
+
+	p.mu.Lock()
+	path := p.statePath
+	state := stateFile{Sessions: make(map[string]savedSession, len(p.entries))}
+	for _, e := range p.entries {
+		state.Sessions[e.session.Name] = savedSession{
+			Base:      e.base,
+			Cookie:    e.session.Cookie,
+			CSRFToken: e.session.CSRFToken,
+			WWWClaim:  e.session.WWWClaim,
+			UpdatedAt: unixOrZero(e.lastRefresh),
+		}
+	}
+	p.mu.Unlock()
+	if path == "" {
+		return
+	}
+
+	err := atomicfile.WritePerm(path, 0600, func(w io.Writer) error {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "    ")
+		return encoder.Encode(state)
+	})
+	if err != nil {
+		log.Printf("Error saving Instagram session state to '%s': %v", path, err)
+	}
+}
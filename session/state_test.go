@@ -0,0 +1,82 @@
+package session
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStateFileRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "sessions.json")
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	cfg := Config{Sessions: []Session{
+		{Name: "a", Cookie: "sessionid=a; csrftoken=a0", CSRFToken: "a0"},
+		{Name: "b", Cookie: "sessionid=b", WWWClaim: "0"},
+	}}
+
+	pool, err := NewPool(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// File yang belum ada bukan error.
+	if err := pool.SetStateFile(path); err != nil {
+		t.Fatalf("SetStateFile(missing) error = %v", err)
+	}
+	current, _ := pool.Acquire()
+	pool.Absorb(current, &http.Response{Header: http.Header{
+		"Set-Cookie":   []string{"csrftoken=a1"},
+		wwwClaimHeader: []string{"hmac.a"},
+	}})
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("state file not written: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("state file mode = %o, want 600", perm)
+	}
+
+	// Setelah restart dengan konfigurasi yang sama, state yang diperbarui dipakai.
+	restarted, err := NewPool(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := restarted.SetStateFile(path); err != nil {
+		t.Fatalf("SetStateFile() error = %v", err)
+	}
+	a, _ := restarted.Acquire()
+	if a.Cookie != "sessionid=a; csrftoken=a1" || a.CSRFToken != "a1" || a.WWWClaim != "hmac.a" {
+		t.Errorf("restored session a = %+v, want refreshed state", a)
+	}
+	b, _ := restarted.Acquire()
+	if b != cfg.Sessions[1] {
+		t.Errorf("restored session b = %+v, want unchanged %+v", b, cfg.Sessions[1])
+	}
+
+	// Kredensial konfigurasi yang diganti menang atas state lama.
+	changed := Config{Sessions: []Session{{Name: "a", Cookie: "sessionid=baru", CSRFToken: "c0"}}}
+	replaced, err := NewPool(changed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := replaced.SetStateFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := replaced.Acquire(); got != changed.Sessions[0] {
+		t.Errorf("session with new credentials = %+v, want %+v", got, changed.Sessions[0])
+	}
+}
+
+func TestSetStateFileInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.json")
+	if err := os.WriteFile(path, []byte("{bukan json"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	pool := Single(Session{Name: "a", Cookie: "sessionid=a"})
+	if err := pool.SetStateFile(path); err == nil {
+		t.Error("SetStateFile(invalid JSON) error = nil, want error")
+	}
+}
@@ -0,0 +1,234 @@
+package split
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Column adalah satu kolom output JSON/CSV. Nilainya sama dengan key JSON-nya.
+type Column string
+
+const (
+	ColOwnerUsername Column = "owner_username"
+	ColText          Column = "text"
+	ColComments      Column = "comments"
+	ColPostURL       Column = "post_url"
+	ColCode          Column = "code"
+	ColCreatedAt     Column = "created_at"      // ISO-8601 (UTC)
+	ColCreatedAtUnix Column = "created_at_unix" // Unix timestamp
+	ColMediaType     Column = "media_type"
+	ColLikes         Column = "likes"
+	ColPlays         Column = "plays"
+	ColDuration      Column = "duration"
+	ColThumbnailURL  Column = "thumbnail_url"
+	ColVideoURL      Column = "video_url"
+	ColHashtags      Column = "hashtags" // Hashtag yang memunculkan postingan (hasil gabungan); di CSV dipisah koma
+)
+
+// DefaultColumns adalah kolom output lama, dipakai jika tidak ada kolom yang dipilih.
+var DefaultColumns = []Column{ColOwnerUsername, ColText, ColComments, ColPostURL}
+
+// AllColumns adalah semua kolom yang tersedia, dalam urutan default-nya.
+var AllColumns = []Column{
+	ColOwnerUsername, ColText, ColComments, ColPostURL, ColCode,
+	ColCreatedAt, ColCreatedAtUnix, ColMediaType, ColLikes, ColPlays,
+	ColDuration, ColThumbnailURL, ColVideoURL, ColHashtags,
+}
+
+// columnHeaders adalah judul kolom CSV dalam bahasa Indonesia (default).
+var columnHeaders = map[Column]string{
+	ColOwnerUsername: "akun yang posting",
+	ColText:          "konten",
+	ColComments:      "jumlah komentar",
+	ColPostURL:       "url postingan",
+	ColCode:          "kode postingan",
+	ColCreatedAt:     "waktu posting",
+	ColCreatedAtUnix: "waktu posting (unix)",
+	ColMediaType:     "tipe media",
+	ColLikes:         "jumlah like",
+	ColPlays:         "jumlah play",
+	ColDuration:      "durasi video (detik)",
+	ColThumbnailURL:  "url thumbnail",
+	ColVideoURL:      "url video",
+	ColHashtags:      "hashtag",
+}
+
+// columnHeadersEN adalah judul kolom CSV dalam bahasa Inggris.
+var columnHeadersEN = map[Column]string{
+	ColOwnerUsername: "owner username",
+	ColText:          "caption",
+	ColComments:      "comments",
+	ColPostURL:       "post url",
+	ColCode:          "shortcode",
+	ColCreatedAt:     "created at",
+	ColCreatedAtUnix: "created at (unix)",
+	ColMediaType:     "media type",
+	ColLikes:         "likes",
+	ColPlays:         "plays",
+	ColDuration:      "video duration (seconds)",
+	ColThumbnailURL:  "thumbnail url",
+	ColVideoURL:      "video url",
+	ColHashtags:      "hashtags",
+}
+
+// Bahasa judul kolom untuk CSVOptions.Language.
+const (
+	LanguageID = "id"
+	LanguageEN = "en"
+)
+
+// headerLanguages memetakan kode bahasa ke judul kolomnya.
+var headerLanguages = map[string]map[Column]string{
+	LanguageID: columnHeaders,
+	LanguageEN: columnHeadersEN,
+}
+
+// ParseColumns mengubah daftar kolom yang dipisah koma (misalnya dari query
+// parameter 'columns') menjadi []Column. String kosong berarti DefaultColumns,
+// dan "all" berarti AllColumns. Kolom yang sama hanya diambil sekali.
+func ParseColumns(s string) ([]Column, error) {
+	switch strings.TrimSpace(s) {
+	case "":
+		return DefaultColumns, nil
+	case "all":
+		return AllColumns, nil
+	}
+	var cols []Column
+	seen := make(map[Column]bool)
+	for _, name := range strings.Split(s, ",") {
+		col := Column(strings.TrimSpace(name))
+		if col == "" || seen[col] {
+			continue
+		}
+		if _, ok := columnHeaders[col]; !ok {
+			return nil, fmt.Errorf("unknown column %q", col)
+		}
+		seen[col] = true
+		cols = append(cols, col)
+	}
+	if len(cols) == 0 {
+		return DefaultColumns, nil
+	}
+	return cols, nil
+}
+
+// Header mengembalikan judul kolom di CSV dalam bahasa Indonesia.
+func (c Column) Header() string {
+	return c.HeaderIn(LanguageID)
+}
+
+// HeaderIn mengembalikan judul kolom dalam bahasa lang (LanguageID atau LanguageEN).
+// Bahasa yang tidak dikenal memakai bahasa Indonesia.
+func (c Column) HeaderIn(lang string) string {
+	headers, ok := headerLanguages[lang]
+	if !ok {
+		headers = columnHeaders
+	}
+	if h, ok := headers[c]; ok {
+		return h
+	}
+	return string(c)
+}
+
+// Value mengembalikan nilai kolom dari post, dengan tipe aslinya (untuk JSON).
+func (c Column) Value(post Post) interface{} {
+	switch c {
+	case ColOwnerUsername:
+		return post.OwnerUsername
+	case ColText:
+		return post.Text
+	case ColComments:
+		return post.Comments
+	case ColPostURL:
+		return post.PostURL
+	case ColCode:
+		return post.Code
+	case ColCreatedAt:
+		return post.CreatedAtISO
+	case ColCreatedAtUnix:
+		return post.CreatedAt
+	case ColMediaType:
+		return post.MediaType
+	case ColLikes:
+		return post.Likes
+	case ColPlays:
+		return post.Plays
+	case ColDuration:
+		return post.Duration
+	case ColThumbnailURL:
+		return post.ThumbnailURL
+	case ColVideoURL:
+		return post.VideoURL
+	case ColHashtags:
+		if post.Hashtags == nil {
+			return []string{}
+		}
+		return post.Hashtags
+	}
+	return nil
+}
+
+// Format mengembalikan nilai kolom dari post sebagai teks (untuk CSV).
+func (c Column) Format(post Post) string {
+	switch v := c.Value(post).(type) {
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []string:
+		return strings.Join(v, ",")
+	}
+	return ""
+}
+
+// Record adalah satu postingan yang hanya berisi kolom terpilih. Di JSON,
+// key-nya ditulis sesuai urutan kolom.
+type Record struct {
+	post Post
+	cols []Column
+}
+
+// MarshalJSON mengimplementasikan json.Marshaler.
+func (r Record) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, col := range r.cols {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(string(col))
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(col.Value(r.post))
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// Records adalah padanan Data untuk postingan yang sudah diproyeksikan ke kolom tertentu.
+type Records struct {
+	Posts []Record `json:"posts"`
+}
+
+// Project memilih kolom cols dari setiap postingan.
+func Project(posts []Post, cols []Column) []Record {
+	records := make([]Record, len(posts))
+	for i, post := range posts {
+		records[i] = Record{post: post, cols: cols}
+	}
+	return records
+}
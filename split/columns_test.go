@@ -0,0 +1,106 @@
+package split
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseColumns(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    []Column
+		wantErr bool
+	}{
+		{"", DefaultColumns, false},
+		{"  ", DefaultColumns, false},
+		{"all", AllColumns, false},
+		{",,", DefaultColumns, false},
+		{"likes", []Column{ColLikes}, false},
+		{"code, likes ,plays", []Column{ColCode, ColLikes, ColPlays}, false},
+		{"likes,code,likes", []Column{ColLikes, ColCode}, false},
+		{"hashtags,created_at_unix", []Column{ColHashtags, ColCreatedAtUnix}, false},
+		{"likes,views", nil, true},
+		{"Likes", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseColumns(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseColumns(%q) error = %v, wantErr %t", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseColumns(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestAllColumnsHaveHeaders(t *testing.T) {
+	for _, col := range AllColumns {
+		for lang, headers := range headerLanguages {
+			if headers[col] == "" {
+				t.Errorf("column %s has no %s header", col, lang)
+			}
+		}
+	}
+	if len(columnHeaders) != len(AllColumns) {
+		t.Errorf("%d columns have headers, but AllColumns has %d", len(columnHeaders), len(AllColumns))
+	}
+}
+
+func TestColumnFormat(t *testing.T) {
+	post := Post{
+		OwnerUsername: "budi", Text: "kopi", Comments: 3, PostURL: "https://www.instagram.com/p/A1/",
+		Code: "A1", CreatedAt: 1714564800, CreatedAtISO: "2024-05-01T12:00:00Z", MediaType: MediaTypeVideo,
+		Likes: 10, Plays: 250, Duration: 12.5, ThumbnailURL: "t", VideoURL: "v",
+		Hashtags: []string{"kopi", "teh"},
+	}
+	want := map[Column]string{
+		ColOwnerUsername: "budi",
+		ColText:          "kopi",
+		ColComments:      "3",
+		ColPostURL:       "https://www.instagram.com/p/A1/",
+		ColCode:          "A1",
+		ColCreatedAt:     "2024-05-01T12:00:00Z",
+		ColCreatedAtUnix: "1714564800",
+		ColMediaType:     MediaTypeVideo,
+		ColLikes:         "10",
+		ColPlays:         "250",
+		ColDuration:      "12.5",
+		ColThumbnailURL:  "t",
+		ColVideoURL:      "v",
+		ColHashtags:      "kopi,teh",
+	}
+	for _, col := range AllColumns {
+		if got := col.Format(post); got != want[col] {
+			t.Errorf("%s.Format() = %q, want %q", col, got, want[col])
+		}
+	}
+	if got := Column("views").Format(post); got != "" {
+		t.Errorf("unknown column Format() = %q, want empty", got)
+	}
+}
+
+func TestProjectJSONKeepsColumnOrder(t *testing.T) {
+	posts := []Post{{OwnerUsername: "budi", Code: "A1", Likes: 10}}
+	tests := []struct {
+		cols []Column
+		want string
+	}{
+		{[]Column{ColLikes, ColCode}, `{"posts":[{"likes":10,"code":"A1"}]}`},
+		{[]Column{ColCode, ColOwnerUsername}, `{"posts":[{"code":"A1","owner_username":"budi"}]}`},
+		// Postingan tanpa hashtag ditulis sebagai array kosong, bukan null.
+		{[]Column{ColHashtags}, `{"posts":[{"hashtags":[]}]}`},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := WriteJSONColumns(&buf, posts, tt.cols); err != nil {
+			t.Fatal(err)
+		}
+		got := strings.Join(strings.Fields(buf.String()), "")
+		if got != tt.want {
+			t.Errorf("WriteJSONColumns(%v) = %s, want %s", tt.cols, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package split
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Quoting menentukan kapan field CSV diapit tanda kutip.
+type Quoting string
+
+const (
+	QuoteMinimal Quoting = "minimal" // Hanya jika perlu (berisi delimiter, kutip, atau baris baru), seperti encoding/csv
+	QuoteAll     Quoting = "all"     // Semua field, termasuk header
+)
+
+// NewlineMode menentukan bagaimana baris baru di dalam field (biasanya caption)
+// ditulis. Record selalu diakhiri \n, kecuali dengan NewlinesCRLF.
+type NewlineMode string
+
+const (
+	NewlinesKeep  NewlineMode = "keep"  // Apa adanya
+	NewlinesLF    NewlineMode = "lf"    // Semua \r\n dan \r menjadi \n
+	NewlinesCRLF  NewlineMode = "crlf"  // Semua baris baru, termasuk akhir setiap record, menjadi \r\n (gaya Excel di Windows)
+	NewlinesSpace NewlineMode = "space" // Setiap deretan baris baru diganti satu spasi, sehingga setiap postingan tepat satu baris
+)
+
+// utf8BOM ditulis di awal file jika CSVOptions.BOM aktif, agar Excel membaca file sebagai UTF-8.
+const utf8BOM = "\ufeff"
+
+// CSVOptions mengatur dialek CSV. Nilai nol menghasilkan output yang sama dengan WriteCSV.
+type CSVOptions struct {
+	Columns  []Column          // Default: DefaultColumns
+	Language string            // Bahasa judul kolom, LanguageID (default) atau LanguageEN
+	Headers  map[Column]string // Judul kolom kustom, menimpa Language untuk kolom yang disebut
+	Comma    rune              // Delimiter. Default: ','
+	Quoting  Quoting           // Default: QuoteMinimal
+	BOM      bool              // Tulis UTF-8 BOM di awal
+	Newlines NewlineMode       // Default: NewlinesKeep
+}
+
+// Validate memeriksa apakah opsi bisa dipakai.
+func (o CSVOptions) Validate() error {
+	switch o.Comma {
+	case 0:
+	case '"', '\r', '\n', utf8.RuneError:
+		return fmt.Errorf("invalid CSV delimiter %q", o.Comma)
+	}
+	switch o.Quoting {
+	case "", QuoteMinimal, QuoteAll:
+	default:
+		return fmt.Errorf("unknown CSV quoting %q (expected minimal or all)", o.Quoting)
+	}
+	switch o.Newlines {
+	case "", NewlinesKeep, NewlinesLF, NewlinesCRLF, NewlinesSpace:
+	default:
+		return fmt.Errorf("unknown CSV newline mode %q (expected keep, lf, crlf or space)", o.Newlines)
+	}
+	if o.Language != "" {
+		if _, ok := headerLanguages[o.Language]; !ok {
+			return fmt.Errorf("unknown CSV header language %q (expected id or en)", o.Language)
+		}
+	}
+	for col := range o.Headers {
+		if _, ok := columnHeaders[col]; !ok {
+			return fmt.Errorf("unknown column %q in CSV headers", col)
+		}
+	}
+	return nil
+}
+
+// header mengembalikan judul kolom col sesuai opsi.
+func (o CSVOptions) header(col Column) string {
+	if h, ok := o.Headers[col]; ok {
+		return h
+	}
+	return col.HeaderIn(o.Language)
+}
+
+// WriteCSVOptions menulis posts sebagai CSV dengan baris header dan dialek opts.
+func WriteCSVOptions(w io.Writer, posts []Post, opts CSVOptions) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	if len(opts.Columns) == 0 {
+		opts.Columns = DefaultColumns
+	}
+	if opts.Comma == 0 {
+		opts.Comma = ','
+	}
+
+	bw := bufio.NewWriter(w)
+	if opts.BOM {
+		bw.WriteString(utf8BOM)
+	}
+
+	header := make([]string, len(opts.Columns))
+	for i, col := range opts.Columns {
+		header[i] = opts.header(col)
+	}
+	if err := writeCSVRecord(bw, header, opts); err != nil {
+		return fmt.Errorf("writing CSV header: %w", err)
+	}
+
+	record := make([]string, len(opts.Columns))
+	for i, post := range posts {
+		for j, col := range opts.Columns {
+			record[j] = normalizeNewlines(col.Format(post), opts.Newlines)
+		}
+		if err := writeCSVRecord(bw, record, opts); err != nil {
+			return fmt.Errorf("writing CSV record for post %d: %w", i+1, err)
+		}
+	}
+	return bw.Flush()
+}
+
+// writeCSVRecord menulis satu baris CSV. Aturan kutipnya mengikuti encoding/csv,
+// ditambah opsi untuk mengutip semua field. Dengan NewlinesCRLF record diakhiri
+// \r\n, seperti csv.Writer.UseCRLF.
+func writeCSVRecord(w *bufio.Writer, record []string, opts CSVOptions) error {
+	for i, field := range record {
+		if i > 0 {
+			w.WriteRune(opts.Comma)
+		}
+		if opts.Quoting != QuoteAll && !fieldNeedsQuotes(field, opts.Comma) {
+			w.WriteString(field)
+			continue
+		}
+		w.WriteByte('"')
+		w.WriteString(strings.ReplaceAll(field, `"`, `""`))
+		w.WriteByte('"')
+	}
+	terminator := "\n"
+	if opts.Newlines == NewlinesCRLF {
+		terminator = "\r\n"
+	}
+	_, err := w.WriteString(terminator)
+	return err
+}
+
+// fieldNeedsQuotes sama dengan aturan di encoding/csv.Writer.
+func fieldNeedsQuotes(field string, comma rune) bool {
+	if field == "" {
+		return false
+	}
+	if field == `\.` {
+		return true
+	}
+	if strings.ContainsRune(field, comma) || strings.ContainsAny(field, "\"\r\n") {
+		return true
+	}
+	return field[0] == ' ' || field[0] == '\t'
+}
+
+// newlineRuns cocok dengan deretan baris baru untuk NewlinesSpace. Spasi dan tab
+// lain di caption tidak disentuh.
+var newlineRuns = regexp.MustCompile(`[\r\n]+`)
+
+// normalizeNewlines menerapkan mode pada baris baru di dalam s.
+func normalizeNewlines(s string, mode NewlineMode) string {
+	if mode == "" || mode == NewlinesKeep || !strings.ContainsAny(s, "\r\n") {
+		return s
+	}
+	if mode == NewlinesSpace {
+		return newlineRuns.ReplaceAllString(s, " ")
+	}
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = strings.ReplaceAll(s, "\r", "\n")
+	if mode == NewlinesCRLF {
+		return strings.ReplaceAll(s, "\n", "\r\n")
+	}
+	return s
+}
@@ -0,0 +1,137 @@
+package split
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteCSVOptions(t *testing.T) {
+	posts := []Post{
+		{OwnerUsername: "budi", Text: "kopi, susu\r\nenak", Comments: 3, PostURL: "https://www.instagram.com/p/A1/", Likes: 10},
+		{OwnerUsername: "sari", Text: `kata "teh"`, Comments: 0, PostURL: "https://www.instagram.com/p/B2/"},
+	}
+	tests := []struct {
+		name string
+		opts CSVOptions
+		want string
+	}{
+		{
+			name: "default sama dengan WriteCSV",
+			opts: CSVOptions{},
+			want: "akun yang posting,konten,jumlah komentar,url postingan\n" +
+				"budi,\"kopi, susu\r\nenak\",3,https://www.instagram.com/p/A1/\n" +
+				"sari,\"kata \"\"teh\"\"\",0,https://www.instagram.com/p/B2/\n",
+		},
+		{
+			name: "header inggris, titik koma, BOM",
+			opts: CSVOptions{Columns: []Column{ColOwnerUsername, ColLikes}, Language: LanguageEN, Comma: ';', BOM: true},
+			want: "\ufeffowner username;likes\nbudi;10\nsari;0\n",
+		},
+		{
+			name: "header kustom menimpa bahasa",
+			opts: CSVOptions{Columns: []Column{ColOwnerUsername, ColComments}, Language: LanguageEN, Headers: map[Column]string{ColComments: "n"}},
+			want: "owner username,n\nbudi,3\nsari,0\n",
+		},
+		{
+			name: "kutip semua field",
+			opts: CSVOptions{Columns: []Column{ColOwnerUsername, ColComments}, Quoting: QuoteAll},
+			want: "\"akun yang posting\",\"jumlah komentar\"\n\"budi\",\"3\"\n\"sari\",\"0\"\n",
+		},
+		{
+			name: "crlf juga untuk akhir record",
+			opts: CSVOptions{Columns: []Column{ColOwnerUsername, ColText}, Newlines: NewlinesCRLF},
+			want: "akun yang posting,konten\r\nbudi,\"kopi, susu\r\nenak\"\r\nsari,\"kata \"\"teh\"\"\"\r\n",
+		},
+		{
+			name: "lf untuk caption dan akhir record",
+			opts: CSVOptions{Columns: []Column{ColOwnerUsername, ColText}, Newlines: NewlinesLF},
+			want: "akun yang posting,konten\nbudi,\"kopi, susu\nenak\"\nsari,\"kata \"\"teh\"\"\"\n",
+		},
+		{
+			name: "tab sebagai delimiter",
+			opts: CSVOptions{Columns: []Column{ColOwnerUsername, ColText}, Comma: '\t', Newlines: NewlinesSpace},
+			want: "akun yang posting\tkonten\nbudi\tkopi, susu enak\nsari\t\"kata \"\"teh\"\"\"\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteCSVOptions(&buf, posts, tt.opts); err != nil {
+				t.Fatalf("WriteCSVOptions() error = %v", err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("WriteCSVOptions() =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteCSVOptionsDefaultMatchesWriteCSV(t *testing.T) {
+	posts := []Post{{OwnerUsername: "budi", Text: "a\nb", Comments: 1, PostURL: "u"}}
+	var legacy, dialect bytes.Buffer
+	if err := WriteCSV(&legacy, posts); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteCSVOptions(&dialect, posts, CSVOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if legacy.String() != dialect.String() {
+		t.Errorf("WriteCSVOptions(zero) = %q, WriteCSV = %q", dialect.String(), legacy.String())
+	}
+}
+
+func TestNormalizeNewlines(t *testing.T) {
+	tests := []struct {
+		in   string
+		mode NewlineMode
+		want string
+	}{
+		{"a\r\nb\rc\nd", NewlinesKeep, "a\r\nb\rc\nd"},
+		{"a\r\nb\rc\nd", "", "a\r\nb\rc\nd"},
+		{"a\r\nb\rc\nd", NewlinesLF, "a\nb\nc\nd"},
+		{"a\r\nb\rc\nd", NewlinesCRLF, "a\r\nb\r\nc\r\nd"},
+		{"a\r\nb\rc\nd", NewlinesSpace, "a b c d"},
+		{"a\n\n\nb", NewlinesSpace, "a b"},
+		// Hanya baris baru yang diubah; spasi ganda dan tab di caption tetap utuh.
+		{"kopi  susu\tenak\n\n#kopi", NewlinesSpace, "kopi  susu\tenak #kopi"},
+		{"  awal\nakhir  ", NewlinesSpace, "  awal akhir  "},
+		{"tanpa baris baru\t ", NewlinesSpace, "tanpa baris baru\t "},
+	}
+	for _, tt := range tests {
+		if got := normalizeNewlines(tt.in, tt.mode); got != tt.want {
+			t.Errorf("normalizeNewlines(%q, %q) = %q, want %q", tt.in, tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestCSVOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    CSVOptions
+		wantErr string
+	}{
+		{"nol", CSVOptions{}, ""},
+		{"lengkap", CSVOptions{Comma: ';', Quoting: QuoteAll, Newlines: NewlinesCRLF, Language: LanguageEN}, ""},
+		{"delimiter kutip", CSVOptions{Comma: '"'}, "invalid CSV delimiter"},
+		{"delimiter baris baru", CSVOptions{Comma: '\n'}, "invalid CSV delimiter"},
+		{"quoting", CSVOptions{Quoting: "some"}, "unknown CSV quoting"},
+		{"newlines", CSVOptions{Newlines: "tab"}, "unknown CSV newline mode"},
+		{"bahasa", CSVOptions{Language: "fr"}, "unknown CSV header language"},
+		{"header kolom asing", CSVOptions{Headers: map[Column]string{"views": "x"}}, "unknown column"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.opts.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package split
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+var (
+	// ErrBadTimestamp dikembalikan jika timestamp batas ('limit') bukan Unix timestamp yang valid.
+	ErrBadTimestamp = errors.New("invalid limit timestamp")
+	// ErrSchemaChanged dikembalikan jika respons Instagram tidak lagi bisa di-parse
+	// sebagai JSON yang dikenal (biasanya karena struktur API berubah).
+	ErrSchemaChanged = errors.New("instagram response schema changed")
+)
+
+// ParseLimit mengubah string Unix timestamp menjadi int64. Error-nya membungkus ErrBadTimestamp.
+func ParseLimit(limitTimestampStr string) (int64, error) {
+	limitTime, err := strconv.ParseInt(limitTimestampStr, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q: %v", ErrBadTimestamp, limitTimestampStr, err)
+	}
+	return limitTime, nil
+}
@@ -0,0 +1,57 @@
+package split
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// NDJSONWriter menulis postingan sebagai newline-delimited JSON: satu objek per
+// baris, tanpa pembungkus {"posts": [...]}. Setiap Write langsung diteruskan ke
+// writer di bawahnya, jadi postingan bisa dikirim selama ekstraksi masih berjalan
+// tanpa menampung semuanya di memori. Tidak aman dipakai dari banyak goroutine.
+type NDJSONWriter struct {
+	w       io.Writer
+	encoder *json.Encoder
+	cols    []Column
+	count   int
+}
+
+// NewNDJSONWriter membuat NDJSONWriter yang menulis kolom cols ke w. cols kosong
+// berarti setiap postingan ditulis lengkap, seperti json.Marshal(Post).
+func NewNDJSONWriter(w io.Writer, cols []Column) *NDJSONWriter {
+	return &NDJSONWriter{w: w, encoder: json.NewEncoder(w), cols: cols}
+}
+
+// Write menulis satu postingan sebagai satu baris.
+func (nw *NDJSONWriter) Write(post Post) error {
+	var v interface{} = post
+	if len(nw.cols) > 0 {
+		v = Record{post: post, cols: nw.cols}
+	}
+	if err := nw.encoder.Encode(v); err != nil {
+		return fmt.Errorf("writing NDJSON record for post %d: %w", nw.count+1, err)
+	}
+	nw.count++
+	// Teruskan ke klien segera jika writer mendukungnya (misalnya http.ResponseWriter).
+	if f, ok := nw.w.(interface{ Flush() }); ok {
+		f.Flush()
+	}
+	return nil
+}
+
+// Count mengembalikan jumlah postingan yang sudah ditulis.
+func (nw *NDJSONWriter) Count() int {
+	return nw.count
+}
+
+// WriteNDJSONColumns menulis posts sebagai newline-delimited JSON dengan kolom cols.
+func WriteNDJSONColumns(w io.Writer, posts []Post, cols []Column) error {
+	nw := NewNDJSONWriter(w, cols)
+	for _, post := range posts {
+		if err := nw.Write(post); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package split
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+// flushRecorder mencatat isi buffer setiap kali Flush dipanggil.
+type flushRecorder struct {
+	bytes.Buffer
+	flushes []string
+}
+
+func (f *flushRecorder) Flush() {
+	f.flushes = append(f.flushes, f.String())
+}
+
+func TestNDJSONWriter(t *testing.T) {
+	posts := []Post{
+		{OwnerUsername: "budi", Text: "baris\nbaru", Code: "A1", Likes: 10},
+		{OwnerUsername: "sari", Code: "B2", Hashtags: []string{"kopi"}},
+	}
+	tests := []struct {
+		name string
+		cols []Column
+		want string
+	}{
+		{
+			name: "kolom terpilih",
+			cols: []Column{ColCode, ColLikes},
+			want: "{\"code\":\"A1\",\"likes\":10}\n{\"code\":\"B2\",\"likes\":0}\n",
+		},
+		{
+			name: "baris baru di caption tetap satu baris",
+			cols: []Column{ColText, ColHashtags},
+			want: "{\"text\":\"baris\\nbaru\",\"hashtags\":[]}\n{\"text\":\"\",\"hashtags\":[\"kopi\"]}\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out flushRecorder
+			nw := NewNDJSONWriter(&out, tt.cols)
+			for _, post := range posts {
+				if err := nw.Write(post); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := out.String(); got != tt.want {
+				t.Errorf("output =\n%s\nwant\n%s", got, tt.want)
+			}
+			if nw.Count() != len(posts) {
+				t.Errorf("Count() = %d, want %d", nw.Count(), len(posts))
+			}
+			// Setiap postingan di-flush segera, bukan di akhir.
+			if len(out.flushes) != len(posts) || out.flushes[0] == out.flushes[1] {
+				t.Errorf("flushes = %q, want one per post", out.flushes)
+			}
+		})
+	}
+}
+
+func TestNDJSONWriterWithoutColumns(t *testing.T) {
+	post := Post{OwnerUsername: "budi", Code: "A1", Likes: 10, Comments: 2}
+	var buf bytes.Buffer
+	if err := WriteNDJSONColumns(&buf, []Post{post, post}, nil); err != nil {
+		t.Fatal(err)
+	}
+	lines := bytes.Split(bytes.TrimSuffix(buf.Bytes(), []byte("\n")), []byte("\n"))
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2: %q", len(lines), buf.String())
+	}
+	// Tanpa kolom, setiap baris adalah Post lengkap.
+	want, _ := json.Marshal(post)
+	for i, line := range lines {
+		if !bytes.Equal(line, want) {
+			t.Errorf("line %d = %s, want %s", i+1, line, want)
+		}
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("disk penuh") }
+
+func TestNDJSONWriterError(t *testing.T) {
+	nw := NewNDJSONWriter(failingWriter{}, DefaultColumns)
+	if err := nw.Write(Post{Code: "A1"}); err == nil {
+		t.Fatal("Write() error = nil, want error")
+	}
+	if nw.Count() != 0 {
+		t.Errorf("Count() = %d after failed write, want 0", nw.Count())
+	}
+}
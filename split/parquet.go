@@ -0,0 +1,68 @@
+package split
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+// parquetPost adalah skema Parquet untuk Post. Berbeda dengan CSV, tipe setiap
+// kolom dipertahankan: angka tetap angka, dan created_at bertipe TIMESTAMP
+// sehingga bisa langsung dipakai di DuckDB/Spark tanpa konversi.
+type parquetPost struct {
+	OwnerUsername string   `parquet:"name=owner_username, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	Text          string   `parquet:"name=text, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Comments      int64    `parquet:"name=comments, type=INT64"`
+	PostURL       string   `parquet:"name=post_url, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Code          string   `parquet:"name=code, type=BYTE_ARRAY, convertedtype=UTF8"`
+	CreatedAt     int64    `parquet:"name=created_at, type=INT64, logicaltype=TIMESTAMP, logicaltype.isadjustedtoutc=true, logicaltype.unit=MILLIS"` // Milidetik sejak epoch (UTC)
+	MediaType     string   `parquet:"name=media_type, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	Likes         int64    `parquet:"name=likes, type=INT64"`
+	Plays         int64    `parquet:"name=plays, type=INT64"`
+	Duration      float64  `parquet:"name=duration, type=DOUBLE"`
+	ThumbnailURL  string   `parquet:"name=thumbnail_url, type=BYTE_ARRAY, convertedtype=UTF8"`
+	VideoURL      string   `parquet:"name=video_url, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Hashtags      []string `parquet:"name=hashtags, type=MAP, convertedtype=LIST, valuetype=BYTE_ARRAY, valueconvertedtype=UTF8"`
+}
+
+// parquetRowGroupSize adalah ukuran row group Parquet dalam byte.
+const parquetRowGroupSize = 64 * 1024 * 1024
+
+// WriteParquet menulis posts sebagai file Parquet (kompresi Snappy) dengan skema
+// tetap yang mencakup semua field Post. Pemilihan kolom tidak berlaku di sini,
+// agar file yang dimuat ke data lake selalu punya skema yang sama.
+func WriteParquet(w io.Writer, posts []Post) error {
+	pw, err := writer.NewParquetWriterFromWriter(w, new(parquetPost), 1)
+	if err != nil {
+		return fmt.Errorf("creating Parquet writer: %w", err)
+	}
+	pw.RowGroupSize = parquetRowGroupSize
+	pw.CompressionType = parquet.CompressionCodec_SNAPPY
+
+	for i, post := range posts {
+		row := parquetPost{
+			OwnerUsername: post.OwnerUsername,
+			Text:          post.Text,
+			Comments:      int64(post.Comments),
+			PostURL:       post.PostURL,
+			Code:          post.Code,
+			CreatedAt:     post.CreatedAt * 1000,
+			MediaType:     post.MediaType,
+			Likes:         int64(post.Likes),
+			Plays:         int64(post.Plays),
+			Duration:      post.Duration,
+			ThumbnailURL:  post.ThumbnailURL,
+			VideoURL:      post.VideoURL,
+			Hashtags:      post.Hashtags,
+		}
+		if err := pw.Write(row); err != nil {
+			return fmt.Errorf("writing Parquet row for post %d: %w", i+1, err)
+		}
+	}
+	if err := pw.WriteStop(); err != nil {
+		return fmt.Errorf("writing Parquet footer: %w", err)
+	}
+	return nil
+}
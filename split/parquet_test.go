@@ -0,0 +1,86 @@
+package split
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/xitongsys/parquet-go-source/buffer"
+	"github.com/xitongsys/parquet-go/reader"
+)
+
+func TestWriteParquetRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		posts []Post
+	}{
+		{"kosong", nil},
+		{"satu", []Post{{OwnerUsername: "budi", Code: "A1", CreatedAt: 1714564800, Likes: 10}}},
+		{"semua field", []Post{
+			{
+				OwnerUsername: "budi", Text: "kopi, susu\n#kopi", Comments: 3, PostURL: "https://www.instagram.com/p/A1/",
+				Code: "A1", CreatedAt: 1714564800, MediaType: MediaTypeVideo, Likes: 10, Plays: 250,
+				Duration: 12.5, ThumbnailURL: "t", VideoURL: "v", Hashtags: []string{"kopi", "กาแฟ"},
+			},
+			{OwnerUsername: "sari", Code: "B2", CreatedAt: 1714568400, MediaType: MediaTypeImage},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteParquet(&buf, tt.posts); err != nil {
+				t.Fatalf("WriteParquet() error = %v", err)
+			}
+			if !bytes.HasPrefix(buf.Bytes(), []byte("PAR1")) || !bytes.HasSuffix(buf.Bytes(), []byte("PAR1")) {
+				t.Fatalf("output is not a Parquet file (missing PAR1 magic)")
+			}
+
+			got := readParquet(t, buf.Bytes())
+			if len(got) != len(tt.posts) {
+				t.Fatalf("read %d rows, want %d", len(got), len(tt.posts))
+			}
+			for i, post := range tt.posts {
+				want := parquetPost{
+					OwnerUsername: post.OwnerUsername,
+					Text:          post.Text,
+					Comments:      int64(post.Comments),
+					PostURL:       post.PostURL,
+					Code:          post.Code,
+					CreatedAt:     post.CreatedAt * 1000, // TIMESTAMP(MILLIS)
+					MediaType:     post.MediaType,
+					Likes:         int64(post.Likes),
+					Plays:         int64(post.Plays),
+					Duration:      post.Duration,
+					ThumbnailURL:  post.ThumbnailURL,
+					VideoURL:      post.VideoURL,
+					Hashtags:      post.Hashtags,
+				}
+				if len(got[i].Hashtags) == 0 && len(want.Hashtags) == 0 {
+					got[i].Hashtags, want.Hashtags = nil, nil
+				}
+				if !reflect.DeepEqual(got[i], want) {
+					t.Errorf("row %d = %+v, want %+v", i, got[i], want)
+				}
+			}
+		})
+	}
+}
+
+// readParquet membaca kembali semua baris file Parquet dengan skema parquetPost.
+func readParquet(t *testing.T, data []byte) []parquetPost {
+	t.Helper()
+	file, err := buffer.NewBufferFile(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pr, err := reader.NewParquetReader(file, new(parquetPost), 1)
+	if err != nil {
+		t.Fatalf("NewParquetReader() error = %v", err)
+	}
+	defer pr.ReadStop()
+	rows := make([]parquetPost, pr.GetNumRows())
+	if err := pr.Read(&rows); err != nil {
+		t.Fatalf("reading rows: %v", err)
+	}
+	return rows
+}
@@ -1,13 +1,14 @@
 package split
 
 import (
-	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"os"
-	"strconv"
 	"time"
+
+	"instagram-scraper/atomicfile"
 )
 
 // Post merepresentasikan struktur data postingan yang ingin kita ekstrak
@@ -16,9 +17,41 @@ type Post struct {
 	Text          string `json:"text"`                     // Konten (caption)
 	Comments      int    `json:"comments,omitempty"`       // Jumlah Komentar
 	PostURL       string `json:"post_url,omitempty"`       // URL Langsung ke Postingan
-	// CreatedAt dihapus dari sini sesuai permintaan untuk output, tapi tetap digunakan untuk filter.
-	// Field lain seperti MediaType, Likes, Views, Share, ImageURL tidak diminta untuk output CSV akhir
-	// jadi tidak disertakan di sini, meskipun datanya bisa diekstrak oleh TopLevelResponse.
+
+	// Field di bawah ini hanya muncul di output JSON/CSV jika kolomnya dipilih
+	// (lihat Column), tetapi selalu diisi dan dipakai oleh paket store.
+	Code         string  `json:"code,omitempty"`            // Shortcode postingan (bagian dari PostURL)
+	CreatedAtISO string  `json:"created_at,omitempty"`      // Waktu posting, ISO-8601 (UTC)
+	CreatedAt    int64   `json:"created_at_unix,omitempty"` // Unix timestamp caption dibuat, juga dipakai untuk filter
+	MediaType    string  `json:"media_type,omitempty"`      // MediaTypeImage atau MediaTypeVideo
+	Likes        int     `json:"likes,omitempty"`           // Jumlah like
+	Plays        int     `json:"plays,omitempty"`           // Jumlah play (video/clips)
+	Duration     float64 `json:"duration,omitempty"`        // Durasi video dalam detik
+	ThumbnailURL string  `json:"thumbnail_url,omitempty"`   // Kandidat gambar pertama dari image_versions2
+	VideoURL     string  `json:"video_url,omitempty"`       // Versi video pertama dari video_versions
+
+	// Hashtags diisi saat hasil beberapa hashtag digabung (scraper.Merge): setiap
+	// hashtag yang memunculkan postingan ini. Kosong untuk scraping satu hashtag.
+	Hashtags []string `json:"hashtags,omitempty"`
+}
+
+// Nilai Post.MediaType.
+const (
+	MediaTypeImage = "image"
+	MediaTypeVideo = "video"
+)
+
+// mediaType mengubah flag is_video menjadi nilai Post.MediaType.
+func mediaType(isVideo bool) string {
+	if isVideo {
+		return MediaTypeVideo
+	}
+	return MediaTypeImage
+}
+
+// isoTime memformat Unix timestamp sebagai ISO-8601 (RFC 3339) dalam UTC.
+func isoTime(unix int64) string {
+	return time.Unix(unix, 0).UTC().Format(time.RFC3339)
 }
 
 // Data adalah struktur untuk menampung koleksi Post yang diekstrak
@@ -26,6 +59,109 @@ type Data struct {
 	Posts []Post `json:"posts"`
 }
 
+// Media adalah satu postingan di dalam section Instagram. Bentuknya sama untuk
+// "fill_items" (clips) maupun "medias" (grid).
+type Media struct {
+	Caption struct {
+		CreatedAt int64  `json:"created_at"` // Waktu posting
+		Text      string `json:"text"`       // Konten
+		User      struct {
+			Username string `json:"username"` // Akun yang posting
+		} `json:"user"`
+	} `json:"caption"`
+	Code         string `json:"code"`          // Untuk URL postingan
+	CommentCount int    `json:"comment_count"` // Jumlah komentar
+	// Field lain yang ada di sample JSON Anda
+	IsVideo        bool `json:"is_video"` // Untuk menentukan tipe media
+	LikeCount      int  `json:"like_count"`
+	PlayCount      int  `json:"play_count"` // Untuk views video (sering ada untuk clips)
+	ImageVersions2 struct {
+		Candidates []struct {
+			URL string `json:"url"`
+		} `json:"candidates"`
+	} `json:"image_versions2"`
+	VideoDashManifest string  `json:"video_dash_manifest"`
+	VideoDuration     float64 `json:"video_duration"`
+	VideoVersions     []struct {
+		URL string `json:"url"`
+	} `json:"video_versions"`
+}
+
+// Section adalah satu blok layout di feed hashtag (grid media atau carousel clips).
+type Section struct {
+	ExploreItemInfo struct {
+		AspectRatio     float64 `json:"aspect_ratio"`
+		Autoplay        bool    `json:"autoplay"`
+		NumColumns      int     `json:"num_columns"`
+		TotalNumColumns int     `json:"total_num_columns"`
+	} `json:"explore_item_info"`
+	FeedType      string `json:"feed_type"`
+	LayoutContent struct {
+		// Ini bisa "fill_items" untuk clips atau "medias" untuk grid media
+		FillItems []struct { // Untuk type "clips" (carousel)
+			Media Media `json:"media"`
+		} `json:"fill_items,omitempty"` // Opsional jika layout bukan "clips"
+		Medias []struct { // Untuk type "media" (grid)
+			Media Media `json:"media"`
+		} `json:"medias,omitempty"` // Opsional jika layout bukan "media"
+	} `json:"layout_content"`
+	LayoutType string `json:"layout_type"`
+}
+
+// Medias mengembalikan semua media di dalam section, apapun tipe layout-nya.
+func (s Section) Medias() []Media {
+	medias := make([]Media, 0, len(s.LayoutContent.FillItems)+len(s.LayoutContent.Medias))
+	for _, item := range s.LayoutContent.FillItems {
+		medias = append(medias, item.Media)
+	}
+	for _, item := range s.LayoutContent.Medias {
+		medias = append(medias, item.Media)
+	}
+	return medias
+}
+
+// FeedSections adalah satu halaman feed hashtag beserta kursor paginasinya.
+// Bentuk yang sama dipakai di "data.top" pada web_info dan di respons endpoint
+// "tags/<hashtag>/sections/" untuk halaman-halaman berikutnya.
+type FeedSections struct {
+	MoreAvailable bool      `json:"more_available"`
+	NextMaxID     string    `json:"next_max_id"`
+	NextMediaIDs  []string  `json:"next_media_ids"`
+	NextPage      int       `json:"next_page"`
+	Sections      []Section `json:"sections"`
+}
+
+// Feed menentukan feed hashtag mana yang diambil dan diekstrak.
+type Feed string
+
+const (
+	FeedTop    Feed = "top"    // Postingan pilihan algoritma ranking Instagram
+	FeedRecent Feed = "recent" // Postingan terbaru secara kronologis
+	FeedBoth   Feed = "both"   // Gabungan top dan recent, duplikat dibuang berdasarkan shortcode
+)
+
+// ParseFeed mengubah nilai query parameter menjadi Feed. String kosong berarti FeedTop.
+func ParseFeed(s string) (Feed, error) {
+	switch Feed(s) {
+	case "":
+		return FeedTop, nil
+	case FeedTop, FeedRecent, FeedBoth:
+		return Feed(s), nil
+	}
+	return "", fmt.Errorf("unknown feed %q (expected top, recent or both)", s)
+}
+
+// Tabs mengembalikan nama tab Instagram ("top"/"recent") yang dicakup feed ini.
+func (f Feed) Tabs() []string {
+	switch f {
+	case FeedRecent:
+		return []string{"recent"}
+	case FeedBoth:
+		return []string{"top", "recent"}
+	}
+	return []string{"top"}
+}
+
 // TopLevelResponse mewakili struktur respons JSON keseluruhan dari posts_surabaya.json Anda.
 // Ini adalah hasil parse dari JSON yang Anda berikan.
 type TopLevelResponse struct {
@@ -44,208 +180,204 @@ type TopLevelResponse struct {
 		MediaCount             int         `json:"media_count"`
 		Name                   string      `json:"name"`
 		ProfilePicURL          string      `json:"profile_pic_url"`
-		Recent                 struct {
-			Sections []interface{} `json:"sections"` // "sections" kosong di sample, jadi interface{}
-		} `json:"recent"`
+		Recent                 FeedSections `json:"recent"` // Feed kronologis, bentuknya sama dengan "top"
 		ShowFollowDropDown     bool        `json:"show_follow_drop_down"`
 		SocialContext          string      `json:"social_context"`
 		SocialContextProfileLinks []interface{} `json:"social_context_profile_links"`
 		Subtitle               string      `json:"subtitle"`
-		Top                    struct {
-			MoreAvailable bool   `json:"more_available"`
-			NextMaxID     string `json:"next_max_id"`
-			NextMediaIDs  []string `json:"next_media_ids"`
-			NextPage      int    `json:"next_page"`
-			Sections      []struct {
-				ExploreItemInfo struct {
-					AspectRatio   float64 `json:"aspect_ratio"`
-					Autoplay      bool    `json:"autoplay"`
-					NumColumns    int     `json:"num_columns"`
-					TotalNumColumns int     `json:"total_num_columns"`
-				} `json:"explore_item_info"`
-				FeedType    string `json:"feed_type"`
-				LayoutContent struct {
-					// Ini bisa "fill_items" untuk clips atau "medias" untuk grid media
-					FillItems []struct { // Untuk type "clips" (carousel)
-						Media struct {
-							Caption struct {
-								CreatedAt   int64 `json:"created_at"` // Waktu posting
-								Text        string `json:"text"`       // Konten
-								User        struct {
-									Username string `json:"username"` // Akun yang posting
-								} `json:"user"`
-							} `json:"caption"`
-							Code         string `json:"code"`          // Untuk URL postingan
-							CommentCount int    `json:"comment_count"` // Jumlah komentar
-							// Field lain yang ada di sample JSON Anda
-							IsVideo bool `json:"is_video"` // Untuk menentukan tipe media
-							LikeCount int `json:"like_count"`
-							PlayCount int `json:"play_count"` // Untuk views video (sering ada untuk clips)
-							ImageVersions2 struct {
-								Candidates []struct {
-									URL string `json:"url"`
-								} `json:"candidates"`
-							} `json:"image_versions2"`
-							VideoDashManifest string `json:"video_dash_manifest"`
-							VideoDuration float64 `json:"video_duration"`
-							VideoVersions []struct {
-								URL string `json:"url"`
-							} `json:"video_versions"`
-						} `json:"media"`
-					} `json:"fill_items,omitempty"` // Opsional jika layout bukan "clips"
-					Medias []struct { // Untuk type "media" (grid)
-						Media struct {
-							Caption struct {
-								CreatedAt   int64 `json:"created_at"` // Waktu posting
-								Text        string `json:"text"`       // Konten
-								User        struct {
-									Username string `json:"username"` // Akun yang posting
-								} `json:"user"`
-							} `json:"caption"`
-							Code         string `json:"code"`          // Untuk URL postingan
-							CommentCount int    `json:"comment_count"` // Jumlah komentar
-							// Field lain yang ada di sample JSON Anda
-							IsVideo bool `json:"is_video"`
-							LikeCount int `json:"like_count"`
-							PlayCount int `json:"play_count"` // Untuk views video (sering ada untuk feed)
-							ImageVersions2 struct {
-								Candidates []struct {
-									URL string `json:"url"`
-								} `json:"candidates"`
-							} `json:"image_versions2"`
-							VideoDashManifest string `json:"video_dash_manifest"`
-							VideoDuration float64 `json:"video_duration"`
-							VideoVersions []struct {
-								URL string `json:"url"`
-							} `json:"video_versions"`
-						} `json:"media"`
-					} `json:"medias,omitempty"` // Opsional jika layout bukan "media"
-				} `json:"layout_content"`
-				LayoutType string `json:"layout_type"`
-			} `json:"sections"`
-		} `json:"top"` // Jalur data sebenarnya ada di dalam "top.sections"
+		Top                    FeedSections `json:"top"` // Jalur data sebenarnya ada di dalam "top.sections"
 		WarningMessage interface{} `json:"warning_message"` // Bisa null
 	} `json:"data"`
 	Status string `json:"status"`
 }
 
-func Split(inputFile string, outputFileBase string, limitTimestampStr string) {
+// FeedSections mengembalikan halaman feed untuk nama tab "top" atau "recent".
+func (r *TopLevelResponse) FeedSections(tab string) *FeedSections {
+	if tab == "recent" {
+		return &r.Data.Recent
+	}
+	return &r.Data.Top
+}
+
+// Split membaca respons mentah dari inputFile, memfilter postingan dari feed yang
+// dipilih berdasarkan limitTimestampStr, lalu menulis <outputFileBase>.json dan .csv.
+//
+// Timestamp yang tidak valid menghasilkan ErrBadTimestamp, dan JSON yang tidak bisa
+// di-parse sama sekali menghasilkan ErrSchemaChanged. Error baca/tulis file dikembalikan apa adanya.
+func Split(inputFile string, outputFileBase string, limitTimestampStr string, feed Feed) error {
 	log.Printf("Starting data splitting and filtering from '%s'...", inputFile)
 
 	bytes, err := os.ReadFile(inputFile)
 	if err != nil {
 		log.Printf("Error reading input file '%s': %v\n", inputFile, err)
-		return
+		return fmt.Errorf("reading input file %s: %w", inputFile, err)
 	}
 	log.Printf("Input file '%s' read successfully. Size: %d bytes.", inputFile, len(bytes))
 
-	limitTime, err := strconv.ParseInt(limitTimestampStr, 10, 64)
+	limitTime, err := ParseLimit(limitTimestampStr)
 	if err != nil {
-		log.Fatalf("FATAL: Error converting timestamp string '%s' to integer: %v", limitTimestampStr, err)
+		log.Printf("Error converting timestamp string '%s' to integer: %v", limitTimestampStr, err)
+		return err
 	}
-	log.Printf("Filtering posts created after or at: %s (Unix: %d)", time.Unix(limitTime, 0).Format("2006-01-02 15:04:05 MST"), limitTime)
 
-	var extractedData Data
-	extractedData.Posts = make([]Post, 0)
+	posts, err := ExtractJSON(bytes, limitTime, feed)
+	if err != nil {
+		return err
+	}
+	return WriteFiles(outputFileBase, posts)
+}
 
+// ExtractJSON mem-parse respons mentah Instagram dan mengekstrak postingan yang
+// dibuat pada atau setelah limitTime. Jika JSON tidak cocok dengan TopLevelResponse,
+// ekstraksi rekursif dipakai sebagai fallback.
+func ExtractJSON(bytes []byte, limitTime int64, feed Feed) ([]Post, error) {
 	var instaResp TopLevelResponse
-	if err := json.Unmarshal(bytes, &instaResp); err == nil {
-		// Log ini sekarang akan mencerminkan total count yang ditemukan di JSON top-level
-		log.Printf("Successfully unmarshalled JSON to TopLevelResponse. Top more_available status: %t", instaResp.Data.Top.MoreAvailable)// count di level top.sections.layout_content.medias atau fill_items
-
-		// ITERASI MELALUI SEMUA SECTIONS DAN MEDIAS DI DALAMNYA
-		filteredCount := 0
-		for _, section := range instaResp.Data.Top.Sections {
-			if len(section.LayoutContent.FillItems) > 0 { // Untuk tipe "clips" atau carousel
-				for _, item := range section.LayoutContent.FillItems {
-					media := item.Media
-					postCreatedAt := media.Caption.CreatedAt
-
-					if postCreatedAt >= limitTime {
-						postURL := fmt.Sprintf("https://www.instagram.com/p/%s/", media.Code)
-						extractedData.Posts = append(extractedData.Posts, Post{
-							OwnerUsername: media.Caption.User.Username,
-							Text:          media.Caption.Text,
-							Comments:      media.CommentCount,
-							PostURL:       postURL,
-						})
-						filteredCount++
-					}
-				}
-			} else if len(section.LayoutContent.Medias) > 0 { // Untuk tipe "media_grid"
-				for _, item := range section.LayoutContent.Medias {
-					media := item.Media
-					postCreatedAt := media.Caption.CreatedAt // Atau media.TakenAt jika ada
-
-					if postCreatedAt >= limitTime {
-						postURL := fmt.Sprintf("https://www.instagram.com/p/%s/", media.Code)
-						extractedData.Posts = append(extractedData.Posts, Post{
-							OwnerUsername: media.Caption.User.Username,
-							Text:          media.Caption.Text,
-							Comments:      media.CommentCount,
-							PostURL:       postURL,
-						})
-						filteredCount++
-					}
-				}
+	err := json.Unmarshal(bytes, &instaResp)
+	if err == nil {
+		return Extract(&instaResp, limitTime, feed), nil
+	}
+	log.Printf("WARNING: Direct Unmarshal to TopLevelResponse failed (%v). This indicates an outdated TopLevelResponse struct. Please update it based on your actual posts.json. Proceeding with recursive extraction as fallback.", err)
+	var rawData interface{}
+	if err := json.Unmarshal(bytes, &rawData); err != nil {
+		log.Printf("Error parsing JSON for recursive extraction: %v", err)
+		return nil, fmt.Errorf("%w: %v", ErrSchemaChanged, err)
+	}
+	return ExtractRaw(rawData, limitTime), nil
+}
+
+// Extract mengambil postingan dari feed yang dipilih pada respons yang sudah
+// di-decode, hanya yang dibuat pada atau setelah limitTime.
+func Extract(instaResp *TopLevelResponse, limitTime int64, feed Feed) []Post {
+	log.Printf("Filtering posts created after or at: %s (Unix: %d)", time.Unix(limitTime, 0).Format("2006-01-02 15:04:05 MST"), limitTime)
+	// Log ini sekarang akan mencerminkan total count yang ditemukan di JSON top-level
+	log.Printf("Successfully unmarshalled JSON to TopLevelResponse. Top more_available status: %t", instaResp.Data.Top.MoreAvailable) // count di level top.sections.layout_content.medias atau fill_items
+
+	extracted := make([]Post, 0)
+
+	// ITERASI MELALUI SEMUA SECTIONS DAN MEDIAS DI DALAMNYA, UNTUK SETIAP FEED YANG DIPILIH
+	extractor := NewExtractor(limitTime)
+	for _, tab := range feed.Tabs() {
+		extracted = append(extracted, extractor.Sections(instaResp.FeedSections(tab).Sections)...)
+	}
+	log.Printf("Finished filtering. %d posts extracted based on timestamp filter.", len(extracted))
+	return extracted
+}
+
+// Extractor mengekstrak postingan secara bertahap, misalnya per halaman saat
+// paginasi masih berjalan. Postingan yang lebih lama dari limit dibuang, dan
+// postingan yang muncul lebih dari sekali (misalnya di top dan recent sekaligus)
+// hanya diambil sekali berdasarkan shortcode.
+type Extractor struct {
+	limitTime int64
+	seen      map[string]bool
+}
+
+// NewExtractor membuat Extractor yang menyaring postingan sebelum limitTime.
+func NewExtractor(limitTime int64) *Extractor {
+	return &Extractor{limitTime: limitTime, seen: make(map[string]bool)}
+}
+
+// Sections mengembalikan postingan baru dari sections, sesuai urutan kemunculannya.
+func (e *Extractor) Sections(sections []Section) []Post {
+	var extracted []Post
+	for _, section := range sections {
+		for _, media := range section.Medias() { // "fill_items" (clips) maupun "medias" (grid)
+			postCreatedAt := media.Caption.CreatedAt
+
+			if postCreatedAt < e.limitTime {
+				continue
 			}
+			if media.Code != "" && e.seen[media.Code] {
+				continue
+			}
+			e.seen[media.Code] = true
+
+			postURL := fmt.Sprintf("https://www.instagram.com/p/%s/", media.Code)
+			post := Post{
+				OwnerUsername: media.Caption.User.Username,
+				Text:          media.Caption.Text,
+				Comments:      media.CommentCount,
+				PostURL:       postURL,
+				Code:          media.Code,
+				CreatedAtISO:  isoTime(postCreatedAt),
+				CreatedAt:     postCreatedAt,
+				MediaType:     mediaType(media.IsVideo),
+				Likes:         media.LikeCount,
+				Plays:         media.PlayCount,
+				Duration:      media.VideoDuration,
+			}
+			if len(media.ImageVersions2.Candidates) > 0 {
+				post.ThumbnailURL = media.ImageVersions2.Candidates[0].URL
+			}
+			if len(media.VideoVersions) > 0 {
+				post.VideoURL = media.VideoVersions[0].URL
+			}
+			extracted = append(extracted, post)
 		}
-		log.Printf("Finished filtering. %d posts extracted based on timestamp filter.", filteredCount)
-	} else {
-		log.Printf("WARNING: Direct Unmarshal to TopLevelResponse failed (%v). This indicates an outdated TopLevelResponse struct. Please update it based on your actual posts.json. Proceeding with recursive extraction as fallback.", err)
-		var rawData interface{}
-		if err := json.Unmarshal(bytes, &rawData); err != nil {
-			log.Fatalf("FATAL: Error parsing JSON for recursive extraction: %v", err)
-		}
-		// Menggunakan fungsi rekursif dengan parameter yang sesuai dengan Post struct yang disederhanakan
-		extractPostsRecursively(rawData, &extractedData, limitTime) // limitTime tetap dipassing untuk filter rekursif
-		log.Printf("Recursive extraction finished. %d posts extracted.", len(extractedData.Posts))
 	}
+	return extracted
+}
+
+// ExtractRaw adalah fallback untuk JSON yang tidak cocok dengan TopLevelResponse:
+// setiap objek "media" yang punya caption di mana pun di dalam rawData diekstrak.
+func ExtractRaw(rawData interface{}, limitTime int64) []Post {
+	var extractedData Data
+	extractedData.Posts = make([]Post, 0)
+	// Menggunakan fungsi rekursif dengan parameter yang sesuai dengan Post struct yang disederhanakan
+	extractPostsRecursively(rawData, &extractedData, limitTime) // limitTime tetap dipassing untuk filter rekursif
+	log.Printf("Recursive extraction finished. %d posts extracted.", len(extractedData.Posts))
+	return extractedData.Posts
+}
 
-	log.Printf("Total %d posts extracted for output.", len(extractedData.Posts))
+// WriteFiles menulis posts ke <outputFileBase>.json dan <outputFileBase>.csv.
+func WriteFiles(outputFileBase string, posts []Post) error {
+	log.Printf("Total %d posts extracted for output.", len(posts))
+
+	// Kedua file ditulis secara atomik (file sementara + rename), sehingga pembaca
+	// tidak pernah melihat JSON/CSV yang setengah tertulis.
 
 	// --- Output JSON (Opsional, bisa dihapus jika hanya ingin CSV) ---
 	jsonOutputFileName := fmt.Sprintf("%s.json", outputFileBase)
-	outputBytes, err := json.MarshalIndent(extractedData, "", "    ")
-	if err != nil {
-		log.Fatalf("Error marshalling extracted data to JSON: %v", err)
-	}
-	if err := os.WriteFile(jsonOutputFileName, outputBytes, 0644); err != nil {
-		log.Fatalf("Error writing extracted data to JSON file '%s': %v", jsonOutputFileName, err)
+	if err := atomicfile.Write(jsonOutputFileName, func(w io.Writer) error { return WriteJSON(w, posts) }); err != nil {
+		log.Printf("Error writing extracted data to JSON file '%s': %v", jsonOutputFileName, err)
+		return fmt.Errorf("writing %s: %w", jsonOutputFileName, err)
 	}
 	log.Printf("Raw data saved to '%s'", jsonOutputFileName)
 
 	// --- Bagian Output CSV ---
 	csvOutputFileName := fmt.Sprintf("%s.csv", outputFileBase)
-	csvFile, err := os.Create(csvOutputFileName)
-	if err != nil {
-		log.Fatalf("Error creating CSV file '%s': %v", csvOutputFileName, err)
+	if err := atomicfile.Write(csvOutputFileName, func(w io.Writer) error { return WriteCSV(w, posts) }); err != nil {
+		log.Printf("Error writing CSV file '%s': %v", csvOutputFileName, err)
+		return fmt.Errorf("writing %s: %w", csvOutputFileName, err)
 	}
-	defer csvFile.Close()
+	log.Printf("All %d posts written to CSV. Data also saved to '%s'", len(posts), csvOutputFileName)
+	return nil
+}
 
-	writer := csv.NewWriter(csvFile)
-	defer writer.Flush()
+// WriteJSON menulis posts sebagai objek {"posts": [...]} yang di-indent, dengan DefaultColumns.
+func WriteJSON(w io.Writer, posts []Post) error {
+	return WriteJSONColumns(w, posts, DefaultColumns)
+}
 
-	// Tulis header CSV sesuai format baru (username, text, comment_count, url)
-	header := []string{"akun yang posting", "konten", "jumlah komentar", "url postingan"}
-	if err := writer.Write(header); err != nil {
-		log.Fatalf("Error writing CSV header: %v", err)
-	}
-	log.Println("CSV header written.")
-
-	// Tulis data posts ke CSV
-	for i, post := range extractedData.Posts {
-		record := []string{
-			post.OwnerUsername,
-			post.Text,
-			strconv.Itoa(post.Comments),
-			post.PostURL,
-		}
-		if err := writer.Write(record); err != nil {
-			log.Fatalf("Error writing CSV record for post %d: %v", i+1, err)
-		}
+// WriteJSONColumns seperti WriteJSON, tetapi setiap postingan hanya berisi kolom
+// cols, sesuai urutannya.
+func WriteJSONColumns(w io.Writer, posts []Post, cols []Column) error {
+	outputBytes, err := json.MarshalIndent(Records{Posts: Project(posts, cols)}, "", "    ")
+	if err != nil {
+		return fmt.Errorf("marshalling extracted data: %w", err)
 	}
-	log.Printf("All %d posts written to CSV. Data also saved to '%s'", len(extractedData.Posts), csvOutputFileName)
+	_, err = w.Write(outputBytes)
+	return err
+}
+
+// WriteCSV menulis posts sebagai CSV dengan baris header, dengan DefaultColumns.
+func WriteCSV(w io.Writer, posts []Post) error {
+	return WriteCSVColumns(w, posts, DefaultColumns)
+}
+
+// WriteCSVColumns seperti WriteCSV, tetapi hanya dengan kolom cols, sesuai urutannya.
+func WriteCSVColumns(w io.Writer, posts []Post, cols []Column) error {
+	return WriteCSVOptions(w, posts, CSVOptions{Columns: cols})
 }
 
 // Fungsi rekursif untuk mencari dan mengekstrak posts jika struktur JSON tidak langsung cocok dengan TopLevelResponse.
@@ -263,6 +395,11 @@ func extractPostsRecursively(value interface{}, data *Data, limitTime int64) {
 							ownerUsername := ""
 							comments := 0
 							postURL := ""
+							code := ""
+							likes, plays := 0, 0
+							isVideo := false
+							duration := 0.0
+							thumbnailURL, videoURL := "", ""
 
 							if userMap, userOK := captionMap["user"].(map[string]interface{}); userOK {
 								if un, unOK := userMap["username"].(string); unOK {
@@ -272,16 +409,49 @@ func extractPostsRecursively(value interface{}, data *Data, limitTime int64) {
 							if commentCountRaw, commentCountOK := mediaMap["comment_count"].(float64); commentCountOK {
 								comments = int(commentCountRaw)
 							}
-							if code, codeOK := mediaMap["code"].(string); codeOK {
+							if c, codeOK := mediaMap["code"].(string); codeOK {
+								code = c
 								postURL = fmt.Sprintf("https://www.instagram.com/p/%s/", code)
 							}
+							if likeCountRaw, ok := mediaMap["like_count"].(float64); ok {
+								likes = int(likeCountRaw)
+							}
+							if playCountRaw, ok := mediaMap["play_count"].(float64); ok {
+								plays = int(playCountRaw)
+							}
+							if v, ok := mediaMap["is_video"].(bool); ok {
+								isVideo = v
+							}
+							if d, ok := mediaMap["video_duration"].(float64); ok {
+								duration = d
+							}
+							if iv, ok := mediaMap["image_versions2"].(map[string]interface{}); ok {
+								if candidates, ok := iv["candidates"].([]interface{}); ok && len(candidates) > 0 {
+									if c, ok := candidates[0].(map[string]interface{}); ok {
+										thumbnailURL, _ = c["url"].(string)
+									}
+								}
+							}
+							if versions, ok := mediaMap["video_versions"].([]interface{}); ok && len(versions) > 0 {
+								if vv, ok := versions[0].(map[string]interface{}); ok {
+									videoURL, _ = vv["url"].(string)
+								}
+							}
 
 							data.Posts = append(data.Posts, Post{
 								OwnerUsername: ownerUsername,
 								Text:          text,
 								Comments:      comments,
 								PostURL:       postURL,
-								// CreatedAt tidak lagi diisi ke Post struct karena sudah dihapus
+								Code:          code,
+								CreatedAtISO:  isoTime(createdAt),
+								CreatedAt:     createdAt,
+								MediaType:     mediaType(isVideo),
+								Likes:         likes,
+								Plays:         plays,
+								Duration:      duration,
+								ThumbnailURL:  thumbnailURL,
+								VideoURL:      videoURL,
 							})
 						}
 					}
@@ -0,0 +1,358 @@
+package split
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// xlsxSheet adalah nama sheet di file XLSX yang ditulis WriteXLSXColumns.
+const xlsxSheet = "posts"
+
+// xlsxSummaryLabels adalah teks sheet ringkasan WriteXLSXReport dalam satu bahasa.
+type xlsxSummaryLabels struct {
+	Sheet       string // Nama sheet ringkasan
+	Hashtag     string
+	Posts       string
+	Comments    string
+	Likes       string
+	Total       string // Baris total postingan unik
+	TopAccounts string
+}
+
+// xlsxSummaryLanguages memetakan kode bahasa (lihat headerLanguages) ke teks sheet ringkasan.
+var xlsxSummaryLanguages = map[string]xlsxSummaryLabels{
+	LanguageID: {"ringkasan", "hashtag", "jumlah postingan", "total komentar", "total like", "total (unik)", "akun teratas"},
+	LanguageEN: {"summary", "hashtag", "posts", "total comments", "total likes", "total (unique)", "top accounts"},
+}
+
+// summaryLabels mengembalikan teks sheet ringkasan sesuai o.Language.
+// Bahasa yang tidak dikenal memakai bahasa Indonesia.
+func (o CSVOptions) summaryLabels() xlsxSummaryLabels {
+	labels, ok := xlsxSummaryLanguages[o.Language]
+	if !ok {
+		labels = xlsxSummaryLanguages[LanguageID]
+	}
+	return labels
+}
+
+// xlsxTopAccounts adalah jumlah akun teratas yang dicantumkan di sheet ringkasan.
+const xlsxTopAccounts = 10
+
+const (
+	// xlsxMaxHyperlinks adalah batas hyperlink per worksheet di Excel. Setelah itu URL ditulis sebagai teks biasa.
+	xlsxMaxHyperlinks = 65530
+	// xlsxMaxColWidth membatasi lebar kolom otomatis, agar caption panjang tidak membuat kolom selebar layar.
+	xlsxMaxColWidth = 80
+)
+
+// XLSXSheet adalah satu worksheet di laporan XLSX, biasanya postingan satu hashtag.
+type XLSXSheet struct {
+	Name  string // Nama sheet, misalnya hashtag. Disesuaikan dengan aturan nama sheet Excel
+	Posts []Post
+}
+
+// WriteXLSXColumns menulis posts sebagai workbook XLSX dengan satu sheet, baris
+// header, dan kolom opts.Columns. Judul kolom mengikuti opts.Language dan
+// opts.Headers seperti di CSV; opsi dialek CSV lainnya diabaikan. Angka ditulis
+// sebagai angka agar bisa langsung dihitung di spreadsheet, dan URL postingan
+// menjadi hyperlink.
+func WriteXLSXColumns(w io.Writer, posts []Post, opts CSVOptions) error {
+	f := excelize.NewFile()
+	defer f.Close()
+	if err := f.SetSheetName("Sheet1", xlsxSheet); err != nil {
+		return fmt.Errorf("creating XLSX sheet: %w", err)
+	}
+	if err := writeXLSXPosts(f, xlsxSheet, posts, opts); err != nil {
+		return err
+	}
+	return writeXLSXFile(f, w)
+}
+
+// WriteXLSXReport menulis laporan XLSX: sheet ringkasan (jumlah postingan, total
+// komentar dan like per sheet, serta akun teratas), diikuti satu sheet per
+// elemen sheets seperti WriteXLSXColumns. Teks sheet ringkasan, termasuk
+// namanya, mengikuti opts.Language.
+func WriteXLSXReport(w io.Writer, sheets []XLSXSheet, opts CSVOptions) error {
+	labels := opts.summaryLabels()
+	f := excelize.NewFile()
+	defer f.Close()
+	if err := f.SetSheetName("Sheet1", labels.Sheet); err != nil {
+		return fmt.Errorf("creating XLSX sheet: %w", err)
+	}
+
+	used := map[string]bool{strings.ToLower(labels.Sheet): true}
+	names := make([]string, len(sheets))
+	for i, sheet := range sheets {
+		names[i] = xlsxSheetName(sheet.Name, used)
+		if _, err := f.NewSheet(names[i]); err != nil {
+			return fmt.Errorf("creating XLSX sheet %q: %w", names[i], err)
+		}
+		if err := writeXLSXPosts(f, names[i], sheet.Posts, opts); err != nil {
+			return err
+		}
+	}
+	if err := writeXLSXSummary(f, sheets, names, labels); err != nil {
+		return err
+	}
+	return writeXLSXFile(f, w)
+}
+
+// writeXLSXPosts menulis header dan postingan ke sheet, lalu mengatur lebar kolom.
+func writeXLSXPosts(f *excelize.File, sheet string, posts []Post, opts CSVOptions) error {
+	cols := opts.Columns
+	if len(cols) == 0 {
+		cols = DefaultColumns
+	}
+	header := make([]interface{}, len(cols))
+	for i, col := range cols {
+		header[i] = opts.header(col)
+	}
+	if err := writeXLSXHeader(f, sheet, header); err != nil {
+		return err
+	}
+
+	linkStyle, err := f.NewStyle(&excelize.Style{Font: &excelize.Font{Color: "0563C1", Underline: "single"}})
+	if err != nil {
+		return fmt.Errorf("creating XLSX style: %w", err)
+	}
+
+	widths := newXLSXWidths(header)
+	row := make([]interface{}, len(cols))
+	for i, post := range posts {
+		for j, col := range cols {
+			row[j] = col.Value(post)
+			if _, isList := row[j].([]string); isList {
+				row[j] = col.Format(post) // Daftar hashtag ditulis sebagai teks dipisah koma
+			}
+			widths.fit(j, col.Format(post))
+		}
+		cell, _ := excelize.CoordinatesToCellName(1, i+2)
+		if err := f.SetSheetRow(sheet, cell, &row); err != nil {
+			return fmt.Errorf("writing XLSX row for post %d: %w", i+1, err)
+		}
+		if i >= xlsxMaxHyperlinks || post.PostURL == "" {
+			continue
+		}
+		for j, col := range cols {
+			if col != ColPostURL {
+				continue
+			}
+			cell, _ := excelize.CoordinatesToCellName(j+1, i+2)
+			if err := f.SetCellHyperLink(sheet, cell, post.PostURL, "External"); err != nil {
+				return fmt.Errorf("writing XLSX hyperlink for post %d: %w", i+1, err)
+			}
+			if err := f.SetCellStyle(sheet, cell, cell, linkStyle); err != nil {
+				return fmt.Errorf("writing XLSX hyperlink for post %d: %w", i+1, err)
+			}
+		}
+	}
+	return widths.apply(f, sheet)
+}
+
+// writeXLSXSummary menulis sheet ringkasan untuk WriteXLSXReport.
+func writeXLSXSummary(f *excelize.File, sheets []XLSXSheet, names []string, labels xlsxSummaryLabels) error {
+	summary := labels.Sheet
+	header := []interface{}{labels.Hashtag, labels.Posts, labels.Comments, labels.Likes}
+	if err := writeXLSXHeader(f, summary, header); err != nil {
+		return err
+	}
+	widths := newXLSXWidths(header)
+
+	type account struct {
+		username        string
+		posts           int
+		comments, likes int
+	}
+	accounts := make(map[string]*account)
+	seen := make(map[string]bool) // Postingan yang muncul di beberapa sheet hanya dihitung sekali untuk akun
+	var totalPosts, totalComments, totalLikes int
+
+	rowNum := 2
+	for i, sheet := range sheets {
+		comments, likes := 0, 0
+		for _, post := range sheet.Posts {
+			comments += post.Comments
+			likes += post.Likes
+
+			key := post.Code
+			if key == "" {
+				key = post.PostURL
+			}
+			if key != "" && seen[key] {
+				continue
+			}
+			seen[key] = true
+			totalPosts++
+			totalComments += post.Comments
+			totalLikes += post.Likes
+			a, ok := accounts[post.OwnerUsername]
+			if !ok {
+				a = &account{username: post.OwnerUsername}
+				accounts[post.OwnerUsername] = a
+			}
+			a.posts++
+			a.comments += post.Comments
+			a.likes += post.Likes
+		}
+		row := []interface{}{names[i], len(sheet.Posts), comments, likes}
+		if err := setXLSXRow(f, summary, rowNum, row, widths); err != nil {
+			return err
+		}
+		rowNum++
+	}
+	total := []interface{}{labels.Total, totalPosts, totalComments, totalLikes}
+	if err := setXLSXRow(f, summary, rowNum, total, widths); err != nil {
+		return err
+	}
+
+	// Akun teratas: paling banyak postingan, lalu paling banyak like.
+	ranked := make([]*account, 0, len(accounts))
+	for _, a := range accounts {
+		ranked = append(ranked, a)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].posts != ranked[j].posts {
+			return ranked[i].posts > ranked[j].posts
+		}
+		if ranked[i].likes != ranked[j].likes {
+			return ranked[i].likes > ranked[j].likes
+		}
+		return ranked[i].username < ranked[j].username
+	})
+	if len(ranked) > xlsxTopAccounts {
+		ranked = ranked[:xlsxTopAccounts]
+	}
+
+	rowNum += 2
+	accountHeader := []interface{}{labels.TopAccounts, labels.Posts, labels.Comments, labels.Likes}
+	cell, _ := excelize.CoordinatesToCellName(1, rowNum)
+	if err := f.SetSheetRow(summary, cell, &accountHeader); err != nil {
+		return fmt.Errorf("writing XLSX summary: %w", err)
+	}
+	if err := f.SetCellStyle(summary, cell, cell, xlsxBoldStyle(f)); err != nil {
+		return fmt.Errorf("writing XLSX summary: %w", err)
+	}
+	widths.fit(0, labels.TopAccounts)
+	for _, a := range ranked {
+		rowNum++
+		if err := setXLSXRow(f, summary, rowNum, []interface{}{a.username, a.posts, a.comments, a.likes}, widths); err != nil {
+			return err
+		}
+	}
+	return widths.apply(f, summary)
+}
+
+// writeXLSXHeader menulis baris header tebal dan membekukannya saat scroll.
+func writeXLSXHeader(f *excelize.File, sheet string, header []interface{}) error {
+	if err := f.SetSheetRow(sheet, "A1", &header); err != nil {
+		return fmt.Errorf("writing XLSX header: %w", err)
+	}
+	last, _ := excelize.CoordinatesToCellName(len(header), 1)
+	if err := f.SetCellStyle(sheet, "A1", last, xlsxBoldStyle(f)); err != nil {
+		return fmt.Errorf("writing XLSX header: %w", err)
+	}
+	if err := f.SetPanes(sheet, &excelize.Panes{Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft"}); err != nil {
+		return fmt.Errorf("writing XLSX header: %w", err)
+	}
+	return nil
+}
+
+// xlsxBoldStyle mengembalikan ID style tebal. excelize menggunakan ulang style yang sama.
+func xlsxBoldStyle(f *excelize.File) int {
+	id, _ := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	return id
+}
+
+// setXLSXRow menulis row di baris rowNum dan memperbarui widths.
+func setXLSXRow(f *excelize.File, sheet string, rowNum int, row []interface{}, widths xlsxWidths) error {
+	cell, _ := excelize.CoordinatesToCellName(1, rowNum)
+	if err := f.SetSheetRow(sheet, cell, &row); err != nil {
+		return fmt.Errorf("writing XLSX row %d of %s: %w", rowNum, sheet, err)
+	}
+	for i, v := range row {
+		widths.fit(i, fmt.Sprint(v))
+	}
+	return nil
+}
+
+func writeXLSXFile(f *excelize.File, w io.Writer) error {
+	f.SetActiveSheet(0)
+	if _, err := f.WriteTo(w); err != nil {
+		return fmt.Errorf("writing XLSX file: %w", err)
+	}
+	return nil
+}
+
+// xlsxWidths mencatat lebar isi terpanjang setiap kolom (dalam karakter) untuk auto-size.
+type xlsxWidths []int
+
+func newXLSXWidths(header []interface{}) xlsxWidths {
+	widths := make(xlsxWidths, len(header))
+	for i, h := range header {
+		widths.fit(i, fmt.Sprint(h))
+	}
+	return widths
+}
+
+// fit memperlebar kolom i jika s lebih panjang. Hanya baris pertama s yang dihitung,
+// karena caption multi-baris ditampilkan terbungkus.
+func (widths xlsxWidths) fit(i int, s string) {
+	if i >= len(widths) {
+		return
+	}
+	if n := strings.IndexAny(s, "\r\n"); n >= 0 {
+		s = s[:n]
+	}
+	if n := utf8.RuneCountInString(s); n > widths[i] {
+		widths[i] = n
+	}
+}
+
+func (widths xlsxWidths) apply(f *excelize.File, sheet string) error {
+	for i, n := range widths {
+		width := float64(n) + 2 // Sedikit ruang untuk ikon filter dan padding
+		if width > xlsxMaxColWidth {
+			width = xlsxMaxColWidth
+		}
+		col, _ := excelize.ColumnNumberToName(i + 1)
+		if err := f.SetColWidth(sheet, col, col, width); err != nil {
+			return fmt.Errorf("sizing XLSX column %s of %s: %w", col, sheet, err)
+		}
+	}
+	return nil
+}
+
+// xlsxSheetName mengubah name menjadi nama sheet Excel yang valid dan unik:
+// tanpa karakter []:*?/\, maksimal 31 karakter, dan tidak sama (tanpa memperhatikan
+// huruf besar/kecil) dengan nama di used.
+func xlsxSheetName(name string, used map[string]bool) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '_'
+		}
+		return r
+	}, strings.Trim(name, "'"))
+	if name == "" {
+		name = xlsxSheet
+	}
+	name = truncateRunes(name, 31)
+	candidate := name
+	for i := 2; used[strings.ToLower(candidate)]; i++ {
+		suffix := fmt.Sprintf(" (%d)", i)
+		candidate = truncateRunes(name, 31-len(suffix)) + suffix
+	}
+	used[strings.ToLower(candidate)] = true
+	return candidate
+}
+
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
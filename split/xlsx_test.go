@@ -0,0 +1,160 @@
+package split
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// openXLSX membaca workbook yang ditulis ke buf.
+func openXLSX(t *testing.T, buf *bytes.Buffer) *excelize.File {
+	t.Helper()
+	f, err := excelize.OpenReader(buf)
+	if err != nil {
+		t.Fatalf("OpenReader() error = %v", err)
+	}
+	t.Cleanup(func() { f.Close() })
+	return f
+}
+
+// xlsxRows mengembalikan isi sheet sebagai teks.
+func xlsxRows(t *testing.T, f *excelize.File, sheet string) [][]string {
+	t.Helper()
+	rows, err := f.GetRows(sheet)
+	if err != nil {
+		t.Fatalf("GetRows(%q) error = %v", sheet, err)
+	}
+	return rows
+}
+
+// reportSheets adalah dua hashtag yang sama-sama berisi postingan B.
+func reportSheets() []XLSXSheet {
+	a := Post{Code: "A", OwnerUsername: "budi", Comments: 1, Likes: 10, PostURL: "https://www.instagram.com/p/A/"}
+	b := Post{Code: "B", OwnerUsername: "sari", Comments: 2, Likes: 5, PostURL: "https://www.instagram.com/p/B/"}
+	c := Post{Code: "C", OwnerUsername: "budi", Comments: 3, Likes: 1, PostURL: "https://www.instagram.com/p/C/"}
+	return []XLSXSheet{
+		{Name: "kopi", Posts: []Post{a, b}},
+		{Name: "teh", Posts: []Post{b, c}},
+	}
+}
+
+func TestWriteXLSXReport(t *testing.T) {
+	var buf bytes.Buffer
+	opts := CSVOptions{Columns: []Column{ColOwnerUsername, ColLikes, ColPostURL}}
+	if err := WriteXLSXReport(&buf, reportSheets(), opts); err != nil {
+		t.Fatalf("WriteXLSXReport() error = %v", err)
+	}
+	f := openXLSX(t, &buf)
+
+	if got, want := f.GetSheetList(), []string{"ringkasan", "kopi", "teh"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("sheets = %v, want %v", got, want)
+	}
+
+	// Postingan B ada di kedua hashtag, tetapi hanya dihitung sekali di total dan akun teratas.
+	want := [][]string{
+		{"hashtag", "jumlah postingan", "total komentar", "total like"},
+		{"kopi", "2", "3", "15"},
+		{"teh", "2", "5", "6"},
+		{"total (unik)", "3", "6", "16"},
+		nil,
+		{"akun teratas", "jumlah postingan", "total komentar", "total like"},
+		{"budi", "2", "4", "11"},
+		{"sari", "1", "2", "5"},
+	}
+	if got := xlsxRows(t, f, "ringkasan"); !reflect.DeepEqual(got, want) {
+		t.Errorf("summary rows =\n%q\nwant\n%q", got, want)
+	}
+
+	wantKopi := [][]string{
+		{"akun yang posting", "jumlah like", "url postingan"},
+		{"budi", "10", "https://www.instagram.com/p/A/"},
+		{"sari", "5", "https://www.instagram.com/p/B/"},
+	}
+	if got := xlsxRows(t, f, "kopi"); !reflect.DeepEqual(got, wantKopi) {
+		t.Errorf("kopi rows =\n%q\nwant\n%q", got, wantKopi)
+	}
+
+	ok, link, err := f.GetCellHyperLink("kopi", "C2")
+	if err != nil || !ok || link != "https://www.instagram.com/p/A/" {
+		t.Errorf("GetCellHyperLink(kopi, C2) = %t, %q, %v; want the post URL", ok, link, err)
+	}
+}
+
+func TestWriteXLSXReportEnglish(t *testing.T) {
+	var buf bytes.Buffer
+	opts := CSVOptions{
+		Columns:  []Column{ColOwnerUsername, ColLikes},
+		Language: LanguageEN,
+		Headers:  map[Column]string{ColLikes: "Suka"},
+	}
+	if err := WriteXLSXReport(&buf, reportSheets(), opts); err != nil {
+		t.Fatalf("WriteXLSXReport() error = %v", err)
+	}
+	f := openXLSX(t, &buf)
+
+	if got, want := f.GetSheetList(), []string{"summary", "kopi", "teh"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("sheets = %v, want %v", got, want)
+	}
+	summary := xlsxRows(t, f, "summary")
+	if got, want := summary[0], []string{"hashtag", "posts", "total comments", "total likes"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("summary header = %q, want %q", got, want)
+	}
+	if got := summary[3][0]; got != "total (unique)" {
+		t.Errorf("total label = %q, want total (unique)", got)
+	}
+	if got := summary[5][0]; got != "top accounts" {
+		t.Errorf("top accounts label = %q, want top accounts", got)
+	}
+	if got, want := xlsxRows(t, f, "teh")[0], []string{"owner username", "Suka"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("teh header = %q, want %q", got, want)
+	}
+}
+
+func TestWriteXLSXColumns(t *testing.T) {
+	posts := []Post{
+		{OwnerUsername: "budi", Comments: 3, Likes: 10, PostURL: "https://www.instagram.com/p/A/"},
+	}
+	var buf bytes.Buffer
+	opts := CSVOptions{Columns: []Column{ColPostURL, ColComments}, Language: LanguageEN}
+	if err := WriteXLSXColumns(&buf, posts, opts); err != nil {
+		t.Fatalf("WriteXLSXColumns() error = %v", err)
+	}
+	f := openXLSX(t, &buf)
+
+	if got, want := f.GetSheetList(), []string{"posts"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("sheets = %v, want %v", got, want)
+	}
+	want := [][]string{
+		{"post url", "comments"},
+		{"https://www.instagram.com/p/A/", "3"},
+	}
+	if got := xlsxRows(t, f, "posts"); !reflect.DeepEqual(got, want) {
+		t.Errorf("posts rows =\n%q\nwant\n%q", got, want)
+	}
+	// Angka ditulis sebagai angka, bukan teks (sel angka tidak punya atribut t, jadi Unset).
+	if typ, err := f.GetCellType("posts", "B2"); err != nil || (typ != excelize.CellTypeNumber && typ != excelize.CellTypeUnset) {
+		t.Errorf("GetCellType(B2) = %v, %v; want a number", typ, err)
+	}
+	if ok, link, _ := f.GetCellHyperLink("posts", "A2"); !ok || link != posts[0].PostURL {
+		t.Errorf("GetCellHyperLink(A2) = %t, %q; want the post URL", ok, link)
+	}
+}
+
+func TestXLSXSheetName(t *testing.T) {
+	used := map[string]bool{"ringkasan": true}
+	tests := []struct{ in, want string }{
+		{"kopi", "kopi"},
+		{"KOPI", "KOPI (2)"},
+		{"a/b:c", "a_b_c"},
+		{"", "posts"},
+		{"Ringkasan", "Ringkasan (2)"},
+		{"abcdefghijklmnopqrstuvwxyz0123456789", "abcdefghijklmnopqrstuvwxyz01234"},
+	}
+	for _, tt := range tests {
+		if got := xlsxSheetName(tt.in, used); got != tt.want {
+			t.Errorf("xlsxSheetName(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
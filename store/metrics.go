@@ -0,0 +1,187 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"instagram-scraper/tagname"
+)
+
+// ErrNotFound dikembalikan jika postingan atau hashtag yang diminta belum pernah disimpan.
+var ErrNotFound = errors.New("not found in post store")
+
+// Snapshot adalah metrik satu postingan pada satu run.
+type Snapshot struct {
+	RunID      int64 `json:"run_id"`
+	CapturedAt int64 `json:"captured_at"`
+	Comments   int   `json:"comments"`
+	Likes      int   `json:"likes"`
+	Plays      int   `json:"plays"`
+}
+
+// Velocity adalah pertambahan metrik per jam.
+type Velocity struct {
+	CommentsPerHour float64 `json:"comments_per_hour"`
+	LikesPerHour    float64 `json:"likes_per_hour"`
+	PlaysPerHour    float64 `json:"plays_per_hour"`
+}
+
+// velocity menghitung pertambahan per jam dari a ke b. Nol jika tidak ada waktu yang berlalu.
+func velocity(a, b Snapshot) Velocity {
+	hours := float64(b.CapturedAt-a.CapturedAt) / 3600
+	if hours <= 0 {
+		return Velocity{}
+	}
+	return Velocity{
+		CommentsPerHour: float64(b.Comments-a.Comments) / hours,
+		LikesPerHour:    float64(b.Likes-a.Likes) / hours,
+		PlaysPerHour:    float64(b.Plays-a.Plays) / hours,
+	}
+}
+
+// PostHistory adalah kurva pertumbuhan satu postingan.
+type PostHistory struct {
+	Code      string     `json:"code"`
+	Snapshots []Snapshot `json:"snapshots"` // Urut dari yang terlama
+	Velocity  Velocity   `json:"velocity"`  // Dari snapshot pertama ke terakhir
+}
+
+// PostHistory mengembalikan semua snapshot metrik postingan code (satu per run
+// yang memuatnya, dari hashtag mana pun), urut menurut waktu.
+func (s *Store) PostHistory(ctx context.Context, code string) (*PostHistory, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT run_id, captured_at, comments, likes, plays
+		FROM metric_snapshots
+		WHERE code = ?
+		ORDER BY captured_at, run_id`, code)
+	if err != nil {
+		return nil, fmt.Errorf("querying snapshots of post %s: %w", code, err)
+	}
+	defer rows.Close()
+
+	h := &PostHistory{Code: code, Snapshots: []Snapshot{}}
+	for rows.Next() {
+		var snap Snapshot
+		if err := rows.Scan(&snap.RunID, &snap.CapturedAt, &snap.Comments, &snap.Likes, &snap.Plays); err != nil {
+			return nil, fmt.Errorf("scanning snapshot: %w", err)
+		}
+		h.Snapshots = append(h.Snapshots, snap)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("querying snapshots of post %s: %w", code, err)
+	}
+	if len(h.Snapshots) == 0 {
+		return nil, fmt.Errorf("post %s: %w", code, ErrNotFound)
+	}
+	h.Velocity = velocity(h.Snapshots[0], h.Snapshots[len(h.Snapshots)-1])
+	return h, nil
+}
+
+// RunMetrics adalah total metrik semua postingan di satu run hashtag, beserta
+// kecepatan pertambahannya sejak run sebelumnya.
+type RunMetrics struct {
+	RunID     int64 `json:"run_id"`
+	ScrapedAt int64 `json:"scraped_at"`
+	Posts     int   `json:"posts"`
+	Comments  int   `json:"comments"`
+	Likes     int   `json:"likes"`
+	Plays     int   `json:"plays"`
+	// Velocity hanya menghitung postingan yang juga ada di run sebelumnya, sehingga
+	// postingan baru atau yang hilang dari feed tidak dianggap sebagai pertumbuhan.
+	// Nil untuk run pertama.
+	Velocity     *Velocity `json:"velocity,omitempty"`
+	MatchedPosts int       `json:"matched_posts"` // Jumlah postingan yang dipakai untuk Velocity
+}
+
+// HashtagVelocity adalah deret waktu agregat engagement sebuah hashtag.
+type HashtagVelocity struct {
+	Hashtag string       `json:"hashtag"`
+	Runs    []RunMetrics `json:"runs"`              // Urut dari yang terlama
+	Overall *Velocity    `json:"overall,omitempty"` // Dari run pertama ke terakhir, atas postingan yang ada di keduanya
+}
+
+// HashtagVelocity mengembalikan metrik agregat setiap run hashtag sejak since
+// (Unix timestamp, 0 berarti semua run). hashtag dinormalkan dengan tagname.Normalize;
+// hashtag yang tidak valid menghasilkan ErrBadQuery.
+func (s *Store) HashtagVelocity(ctx context.Context, hashtag string, since int64) (*HashtagVelocity, error) {
+	hashtag, err := tagname.Normalize(hashtag)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBadQuery, err)
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT r.id, r.scraped_at, m.code, m.comments, m.likes, m.plays
+		FROM runs r
+		JOIN metric_snapshots m ON m.run_id = r.id
+		WHERE r.hashtag = ? AND r.scraped_at >= ?
+		ORDER BY r.scraped_at, r.id`, hashtag, since)
+	if err != nil {
+		return nil, fmt.Errorf("querying runs of hashtag %s: %w", hashtag, err)
+	}
+	defer rows.Close()
+
+	// Snapshot per postingan di setiap run, untuk mencocokkan postingan antar run.
+	var runs []RunMetrics
+	var perRun []map[string]Snapshot
+	for rows.Next() {
+		var runID, scrapedAt int64
+		var code string
+		var snap Snapshot
+		if err := rows.Scan(&runID, &scrapedAt, &code, &snap.Comments, &snap.Likes, &snap.Plays); err != nil {
+			return nil, fmt.Errorf("scanning snapshot: %w", err)
+		}
+		snap.RunID, snap.CapturedAt = runID, scrapedAt
+		if len(runs) == 0 || runs[len(runs)-1].RunID != runID {
+			runs = append(runs, RunMetrics{RunID: runID, ScrapedAt: scrapedAt})
+			perRun = append(perRun, make(map[string]Snapshot))
+		}
+		run := &runs[len(runs)-1]
+		run.Posts++
+		run.Comments += snap.Comments
+		run.Likes += snap.Likes
+		run.Plays += snap.Plays
+		perRun[len(perRun)-1][code] = snap
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("querying runs of hashtag %s: %w", hashtag, err)
+	}
+	if len(runs) == 0 {
+		return nil, fmt.Errorf("hashtag %s: %w", hashtag, ErrNotFound)
+	}
+
+	hv := &HashtagVelocity{Hashtag: hashtag, Runs: runs}
+	for i := 1; i < len(runs); i++ {
+		v, matched := matchedVelocity(perRun[i-1], perRun[i])
+		runs[i].Velocity, runs[i].MatchedPosts = v, matched
+	}
+	if len(runs) > 1 {
+		hv.Overall, _ = matchedVelocity(perRun[0], perRun[len(perRun)-1])
+	}
+	return hv, nil
+}
+
+// matchedVelocity menjumlahkan metrik postingan yang ada di from dan to, lalu
+// menghitung kecepatan pertambahannya. Nil jika tidak ada postingan yang cocok.
+func matchedVelocity(from, to map[string]Snapshot) (*Velocity, int) {
+	var a, b Snapshot
+	matched := 0
+	for code, end := range to {
+		start, ok := from[code]
+		if !ok {
+			continue
+		}
+		matched++
+		a.CapturedAt, b.CapturedAt = start.CapturedAt, end.CapturedAt
+		a.Comments += start.Comments
+		a.Likes += start.Likes
+		a.Plays += start.Plays
+		b.Comments += end.Comments
+		b.Likes += end.Likes
+		b.Plays += end.Plays
+	}
+	if matched == 0 {
+		return nil, 0
+	}
+	v := velocity(a, b)
+	return &v, matched
+}
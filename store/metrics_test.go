@@ -0,0 +1,138 @@
+package store
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"instagram-scraper/split"
+)
+
+func TestVelocity(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b Snapshot
+		want Velocity
+	}{
+		{"dua jam", Snapshot{CapturedAt: 0, Comments: 10, Likes: 100, Plays: 0}, Snapshot{CapturedAt: 7200, Comments: 30, Likes: 160, Plays: 50}, Velocity{10, 30, 25}},
+		{"metrik turun", Snapshot{CapturedAt: 0, Likes: 100}, Snapshot{CapturedAt: 3600, Likes: 90}, Velocity{LikesPerHour: -10}},
+		{"waktu sama", Snapshot{CapturedAt: 10, Likes: 1}, Snapshot{CapturedAt: 10, Likes: 5}, Velocity{}},
+		{"urutan terbalik", Snapshot{CapturedAt: 20, Likes: 1}, Snapshot{CapturedAt: 10, Likes: 5}, Velocity{}},
+	}
+	for _, tt := range tests {
+		if got := velocity(tt.a, tt.b); got != tt.want {
+			t.Errorf("%s: velocity() = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPostHistory(t *testing.T) {
+	s := openTestStore(t)
+	start := time.Unix(0, 0)
+	runs := []struct {
+		hashtag string
+		at      time.Time
+		likes   int
+	}{
+		{"kopi", start, 10},
+		{"teh", start.Add(time.Hour), 20}, // Snapshot dari hashtag lain ikut dihitung
+		{"kopi", start.Add(4 * time.Hour), 50},
+	}
+	for _, r := range runs {
+		if _, err := s.SaveRun(r.hashtag, r.at, []split.Post{{Code: "A", Likes: r.likes, Comments: r.likes / 10}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	h, err := s.PostHistory(bg, "A")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var likes []int
+	for _, snap := range h.Snapshots {
+		likes = append(likes, snap.Likes)
+	}
+	if !reflect.DeepEqual(likes, []int{10, 20, 50}) {
+		t.Errorf("snapshot likes = %v, want [10 20 50]", likes)
+	}
+	if want := (Velocity{CommentsPerHour: 1, LikesPerHour: 10}); h.Velocity != want {
+		t.Errorf("velocity = %+v, want %+v", h.Velocity, want)
+	}
+
+	if _, err := s.PostHistory(bg, "tidakada"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("PostHistory(unknown) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestHashtagVelocity(t *testing.T) {
+	s := openTestStore(t)
+	start := time.Unix(0, 0)
+	runs := []struct {
+		at    time.Time
+		posts []split.Post
+	}{
+		{start, []split.Post{{Code: "A", Likes: 10}, {Code: "B", Likes: 100}}},
+		// B hilang dari feed dan C baru muncul: hanya A yang dipakai untuk kecepatan.
+		{start.Add(time.Hour), []split.Post{{Code: "A", Likes: 40}, {Code: "C", Likes: 1000}}},
+		// Tidak ada postingan yang sama dengan run sebelumnya.
+		{start.Add(2 * time.Hour), []split.Post{{Code: "D", Likes: 5}}},
+		{start.Add(4 * time.Hour), []split.Post{{Code: "A", Likes: 90}, {Code: "D", Likes: 25}}},
+	}
+	for _, r := range runs {
+		if _, err := s.SaveRun("#Kopi", r.at, r.posts); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Run hashtag lain tidak ikut dihitung.
+	if _, err := s.SaveRun("teh", start.Add(time.Hour), []split.Post{{Code: "A", Likes: 999}}); err != nil {
+		t.Fatal(err)
+	}
+
+	hv, err := s.HashtagVelocity(bg, "KOPI", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hv.Hashtag != "kopi" || len(hv.Runs) != 4 {
+		t.Fatalf("HashtagVelocity() = %s with %d runs, want kopi with 4", hv.Hashtag, len(hv.Runs))
+	}
+	tests := []struct {
+		posts, likes int
+		velocity     *Velocity
+		matched      int
+	}{
+		{2, 110, nil, 0},
+		{2, 1040, &Velocity{LikesPerHour: 30}, 1},
+		{1, 5, nil, 0},
+		{2, 115, &Velocity{LikesPerHour: 10}, 1},
+	}
+	for i, want := range tests {
+		run := hv.Runs[i]
+		if run.Posts != want.posts || run.Likes != want.likes || run.MatchedPosts != want.matched || !reflect.DeepEqual(run.Velocity, want.velocity) {
+			t.Errorf("run %d = {Posts: %d, Likes: %d, Velocity: %+v, Matched: %d}, want {%d, %d, %+v, %d}",
+				i+1, run.Posts, run.Likes, run.Velocity, run.MatchedPosts, want.posts, want.likes, want.velocity, want.matched)
+		}
+	}
+	// Dari run pertama ke terakhir hanya A yang ada di keduanya: 10 -> 90 dalam 4 jam.
+	if want := (&Velocity{LikesPerHour: 20}); !reflect.DeepEqual(hv.Overall, want) {
+		t.Errorf("overall = %+v, want %+v", hv.Overall, want)
+	}
+
+	since, err := s.HashtagVelocity(bg, "kopi", start.Add(2*time.Hour).Unix())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(since.Runs) != 2 || since.Runs[0].Velocity != nil {
+		t.Errorf("HashtagVelocity(since) runs = %+v, want the last 2 with no velocity for the first", since.Runs)
+	}
+}
+
+func TestHashtagVelocityErrors(t *testing.T) {
+	s := openTestStore(t)
+	if _, err := s.HashtagVelocity(bg, "kopi", 0); !errors.Is(err, ErrNotFound) {
+		t.Errorf("HashtagVelocity(no runs) error = %v, want ErrNotFound", err)
+	}
+	if _, err := s.HashtagVelocity(bg, "kopi susu", 0); !errors.Is(err, ErrBadQuery) {
+		t.Errorf("HashtagVelocity(invalid) error = %v, want ErrBadQuery", err)
+	}
+}
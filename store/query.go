@@ -0,0 +1,241 @@
+package store
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"instagram-scraper/tagname"
+)
+
+var (
+	// ErrBadQuery dikembalikan jika Query berisi nilai yang tidak valid (misalnya kolom sort yang tidak dikenal).
+	ErrBadQuery = errors.New("invalid query")
+	// ErrBadCursor dikembalikan jika cursor paginasi rusak atau berasal dari urutan sort yang berbeda.
+	ErrBadCursor = errors.New("invalid cursor")
+)
+
+const (
+	// DefaultQueryLimit adalah jumlah postingan per halaman jika Query.Limit nol.
+	DefaultQueryLimit = 50
+	// MaxQueryLimit adalah batas atas Query.Limit.
+	MaxQueryLimit = 500
+)
+
+// sortColumns memetakan nilai Query.Sort ke kolom tabel posts. Semua kolomnya
+// INTEGER, sehingga cursor cukup menyimpan satu nilai int64 dan shortcode.
+var sortColumns = map[string]string{
+	"created_at": "created_at",
+	"comments":   "comments",
+	"likes":      "likes",
+	"plays":      "plays",
+	"first_seen": "first_seen",
+	"last_seen":  "last_seen",
+}
+
+// Query adalah filter, urutan, dan paginasi untuk QueryPosts. Field bernilai nol diabaikan.
+type Query struct {
+	Hashtag       string // Postingan yang pernah muncul di hashtag ini
+	OwnerUsername string // Username pemilik, persis (tidak case-sensitive)
+	MediaType     string // split.MediaTypeImage atau split.MediaTypeVideo
+	Caption       string // Substring caption (tidak case-sensitive)
+	CreatedAfter  int64  // Unix timestamp, inklusif
+	CreatedBefore int64  // Unix timestamp, eksklusif
+	MinComments   int
+	MinLikes      int
+
+	Sort   string // Salah satu kunci sortColumns. Default: created_at
+	Desc   bool   // Urutan menurun
+	Limit  int    // Default: DefaultQueryLimit, maksimal MaxQueryLimit
+	Cursor string // NextCursor dari halaman sebelumnya
+}
+
+// StoredPost adalah satu postingan di database beserta semua hashtag-nya.
+type StoredPost struct {
+	Code          string   `json:"code"`
+	OwnerUsername string   `json:"owner_username"`
+	Text          string   `json:"text"`
+	PostURL       string   `json:"post_url"`
+	CreatedAtISO  string   `json:"created_at"`      // ISO-8601 (UTC), sama seperti di /posts
+	CreatedAt     int64    `json:"created_at_unix"` // Unix timestamp
+	MediaType     string   `json:"media_type,omitempty"`
+	Comments      int      `json:"comments"`
+	Likes         int      `json:"likes"`
+	Plays         int      `json:"plays"`
+	FirstSeen     int64    `json:"first_seen"`
+	LastSeen      int64    `json:"last_seen"`
+	Hashtags      []string `json:"hashtags"`
+}
+
+// cursor adalah posisi terakhir sebuah halaman: nilai kolom sort dan shortcode
+// postingan terakhir. Sort dan Desc ikut disimpan agar cursor tidak dipakai
+// dengan urutan yang berbeda.
+type cursor struct {
+	Sort  string `json:"s"`
+	Desc  bool   `json:"d,omitempty"`
+	Value int64  `json:"v"`
+	Code  string `json:"c"`
+}
+
+func (c cursor) encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(s string) (cursor, error) {
+	var c cursor
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, ErrBadCursor
+	}
+	if err := json.Unmarshal(b, &c); err != nil {
+		return c, ErrBadCursor
+	}
+	return c, nil
+}
+
+// QueryPosts mengembalikan satu halaman postingan yang cocok dengan q. Paginasi
+// memakai keyset (kolom sort, shortcode), jadi halaman berikutnya tetap konsisten
+// meskipun ada postingan baru yang disimpan di antaranya. nextCursor kosong
+// berarti tidak ada halaman berikutnya.
+func (s *Store) QueryPosts(ctx context.Context, q Query) (posts []StoredPost, nextCursor string, err error) {
+	if q.Sort == "" {
+		q.Sort = "created_at"
+	}
+	column, ok := sortColumns[q.Sort]
+	if !ok {
+		return nil, "", fmt.Errorf("%w: unknown sort %q", ErrBadQuery, q.Sort)
+	}
+	if q.Limit < 0 {
+		return nil, "", fmt.Errorf("%w: negative limit", ErrBadQuery)
+	}
+	if q.Limit == 0 {
+		q.Limit = DefaultQueryLimit
+	}
+	if q.Limit > MaxQueryLimit {
+		q.Limit = MaxQueryLimit
+	}
+
+	var where []string
+	var args []interface{}
+	if q.Hashtag != "" {
+		// Hashtag disimpan dalam bentuk normal, jadi "#Kopi" juga menemukan postingan "kopi".
+		hashtag, err := tagname.Normalize(q.Hashtag)
+		if err != nil {
+			return nil, "", fmt.Errorf("%w: %v", ErrBadQuery, err)
+		}
+		q.Hashtag = hashtag
+		where = append(where, `EXISTS (SELECT 1 FROM post_hashtags h WHERE h.code = p.code AND h.hashtag = ?)`)
+		args = append(args, q.Hashtag)
+	}
+	if q.OwnerUsername != "" {
+		where = append(where, `p.owner_username = ? COLLATE NOCASE`)
+		args = append(args, q.OwnerUsername)
+	}
+	if q.MediaType != "" {
+		where = append(where, `p.media_type = ?`)
+		args = append(args, q.MediaType)
+	}
+	if q.Caption != "" {
+		// instr+lower alih-alih LIKE agar '%' dan '_' di input tidak dianggap wildcard.
+		where = append(where, `instr(lower(p.text), lower(?)) > 0`)
+		args = append(args, q.Caption)
+	}
+	if q.CreatedAfter != 0 {
+		where = append(where, `p.created_at >= ?`)
+		args = append(args, q.CreatedAfter)
+	}
+	if q.CreatedBefore != 0 {
+		where = append(where, `p.created_at < ?`)
+		args = append(args, q.CreatedBefore)
+	}
+	if q.MinComments > 0 {
+		where = append(where, `p.comments >= ?`)
+		args = append(args, q.MinComments)
+	}
+	if q.MinLikes > 0 {
+		where = append(where, `p.likes >= ?`)
+		args = append(args, q.MinLikes)
+	}
+
+	op, dir := ">", "ASC"
+	if q.Desc {
+		op, dir = "<", "DESC"
+	}
+	if q.Cursor != "" {
+		c, err := decodeCursor(q.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		if c.Sort != q.Sort || c.Desc != q.Desc {
+			return nil, "", fmt.Errorf("%w: cursor was issued for a different sort order", ErrBadCursor)
+		}
+		where = append(where, fmt.Sprintf(`(p.%[1]s %[2]s ? OR (p.%[1]s = ? AND p.code %[2]s ?))`, column, op))
+		args = append(args, c.Value, c.Value, c.Code)
+	}
+
+	query := `
+		SELECT p.code, p.owner_username, p.text, p.post_url, p.created_at, p.media_type,
+		       p.comments, p.likes, p.plays, p.first_seen, p.last_seen,
+		       COALESCE((SELECT group_concat(h.hashtag, ',') FROM post_hashtags h WHERE h.code = p.code), '')
+		FROM posts p`
+	if len(where) > 0 {
+		query += "\n\t\tWHERE " + strings.Join(where, " AND ")
+	}
+	// Ambil satu baris ekstra untuk mengetahui apakah masih ada halaman berikutnya.
+	query += fmt.Sprintf("\n\t\tORDER BY p.%[1]s %[2]s, p.code %[2]s\n\t\tLIMIT ?", column, dir)
+	args = append(args, q.Limit+1)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, "", fmt.Errorf("querying posts: %w", err)
+	}
+	defer rows.Close()
+
+	posts = []StoredPost{}
+	for rows.Next() {
+		var p StoredPost
+		var hashtags string
+		if err := rows.Scan(&p.Code, &p.OwnerUsername, &p.Text, &p.PostURL, &p.CreatedAt, &p.MediaType,
+			&p.Comments, &p.Likes, &p.Plays, &p.FirstSeen, &p.LastSeen, &hashtags); err != nil {
+			return nil, "", fmt.Errorf("scanning post: %w", err)
+		}
+		p.CreatedAtISO = time.Unix(p.CreatedAt, 0).UTC().Format(time.RFC3339)
+		p.Hashtags = []string{}
+		if hashtags != "" {
+			p.Hashtags = strings.Split(hashtags, ",")
+		}
+		posts = append(posts, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", fmt.Errorf("querying posts: %w", err)
+	}
+
+	if len(posts) > q.Limit {
+		posts = posts[:q.Limit]
+		last := posts[len(posts)-1]
+		nextCursor = cursor{Sort: q.Sort, Desc: q.Desc, Value: sortValue(last, q.Sort), Code: last.Code}.encode()
+	}
+	return posts, nextCursor, nil
+}
+
+// sortValue mengembalikan nilai kolom sort dari p, untuk disimpan di cursor.
+func sortValue(p StoredPost, sort string) int64 {
+	switch sort {
+	case "comments":
+		return int64(p.Comments)
+	case "likes":
+		return int64(p.Likes)
+	case "plays":
+		return int64(p.Plays)
+	case "first_seen":
+		return p.FirstSeen
+	case "last_seen":
+		return p.LastSeen
+	}
+	return p.CreatedAt
+}
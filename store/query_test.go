@@ -0,0 +1,207 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"instagram-scraper/split"
+)
+
+// seedQueryStore menyimpan enam postingan; beberapa punya nilai likes yang sama
+// agar urutan cadangan (shortcode) ikut teruji.
+func seedQueryStore(t *testing.T) *Store {
+	t.Helper()
+	s := openTestStore(t)
+	at := time.Unix(5000, 0)
+	if _, err := s.SaveRun("kopi", at, []split.Post{
+		{Code: "A", OwnerUsername: "Budi", Text: "Kopi susu 100%", CreatedAt: 100, Likes: 10, Comments: 1, MediaType: split.MediaTypeImage},
+		{Code: "B", OwnerUsername: "sari", Text: "kopi_hitam", CreatedAt: 200, Likes: 30, Comments: 5, MediaType: split.MediaTypeVideo},
+		{Code: "C", OwnerUsername: "budi", Text: "teh", CreatedAt: 300, Likes: 10, Comments: 0, MediaType: split.MediaTypeImage},
+		{Code: "D", OwnerUsername: "eka", Text: "KOPI pagi", CreatedAt: 400, Likes: 20, Comments: 2, MediaType: split.MediaTypeImage},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.SaveRun("teh", at, []split.Post{
+		{Code: "E", OwnerUsername: "eka", Text: "teh manis", CreatedAt: 500, Likes: 10, Comments: 9, MediaType: split.MediaTypeVideo},
+		{Code: "F", OwnerUsername: "fajar", Text: "teh tarik", CreatedAt: 600, Likes: 0, Comments: 0, MediaType: split.MediaTypeImage},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestQueryPostsFilters(t *testing.T) {
+	s := seedQueryStore(t)
+	tests := []struct {
+		name string
+		q    Query
+		want []string
+	}{
+		{"semua", Query{}, []string{"A", "B", "C", "D", "E", "F"}},
+		{"hashtag dinormalkan", Query{Hashtag: "#KOPI"}, []string{"A", "B", "C", "D"}},
+		{"username tidak case-sensitive", Query{OwnerUsername: "BUDI"}, []string{"A", "C"}},
+		{"media type", Query{MediaType: split.MediaTypeVideo}, []string{"B", "E"}},
+		{"caption tidak case-sensitive", Query{Caption: "kopi"}, []string{"A", "B", "D"}},
+		{"caption '%' bukan wildcard", Query{Caption: "100%"}, []string{"A"}},
+		{"caption '_' bukan wildcard", Query{Caption: "i_h"}, []string{"B"}},
+		{"rentang created_at", Query{CreatedAfter: 200, CreatedBefore: 400}, []string{"B", "C"}},
+		{"min komentar dan like", Query{MinComments: 1, MinLikes: 20}, []string{"B", "D"}},
+		{"gabungan", Query{Hashtag: "kopi", MediaType: split.MediaTypeImage, MinLikes: 10}, []string{"A", "C", "D"}},
+		{"urut likes menurun", Query{Sort: "likes", Desc: true}, []string{"B", "D", "E", "C", "A", "F"}},
+		{"urut komentar", Query{Sort: "comments"}, []string{"C", "F", "A", "D", "B", "E"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			posts, next, err := s.QueryPosts(bg, tt.q)
+			if err != nil {
+				t.Fatalf("QueryPosts() error = %v", err)
+			}
+			if got := codes(posts); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("QueryPosts() = %v, want %v", got, tt.want)
+			}
+			if next != "" {
+				t.Errorf("next cursor = %q, want none", next)
+			}
+		})
+	}
+}
+
+func TestStoredPostJSONTimes(t *testing.T) {
+	s := seedQueryStore(t)
+	posts, _, err := s.QueryPosts(bg, Query{OwnerUsername: "sari"})
+	if err != nil || len(posts) != 1 {
+		t.Fatalf("QueryPosts() = %v, %v; want post B", posts, err)
+	}
+	body, err := json.Marshal(posts[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatal(err)
+	}
+	// Sama seperti split.Post di /posts: created_at ISO, created_at_unix angka.
+	if got["created_at"] != "1970-01-01T00:03:20Z" {
+		t.Errorf("created_at = %v, want 1970-01-01T00:03:20Z", got["created_at"])
+	}
+	if got["created_at_unix"] != float64(200) {
+		t.Errorf("created_at_unix = %v, want 200", got["created_at_unix"])
+	}
+}
+
+func TestQueryPostsCursorPaging(t *testing.T) {
+	s := seedQueryStore(t)
+	tests := []struct {
+		name  string
+		sort  string
+		desc  bool
+		limit int
+		want  [][]string
+	}{
+		{"created_at naik", "", false, 4, [][]string{{"A", "B", "C", "D"}, {"E", "F"}}},
+		{"created_at turun", "created_at", true, 2, [][]string{{"F", "E"}, {"D", "C"}, {"B", "A"}}},
+		// Tiga postingan dengan likes 10 terpotong di antara halaman tanpa hilang atau berulang.
+		{"likes naik dengan nilai sama", "likes", false, 2, [][]string{{"F", "A"}, {"C", "E"}, {"D", "B"}}},
+		{"likes turun dengan nilai sama", "likes", true, 3, [][]string{{"B", "D", "E"}, {"C", "A", "F"}}},
+		{"satu per halaman", "comments", true, 1, [][]string{{"E"}, {"B"}, {"D"}, {"A"}, {"F"}, {"C"}}},
+		{"limit pas", "created_at", false, 6, [][]string{{"A", "B", "C", "D", "E", "F"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := Query{Sort: tt.sort, Desc: tt.desc, Limit: tt.limit}
+			var got [][]string
+			for page := 0; page < 10; page++ {
+				posts, next, err := s.QueryPosts(bg, q)
+				if err != nil {
+					t.Fatalf("page %d: QueryPosts() error = %v", page+1, err)
+				}
+				got = append(got, codes(posts))
+				if next == "" {
+					break
+				}
+				q.Cursor = next
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("pages = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryPostsCursorStableAfterInsert(t *testing.T) {
+	s := seedQueryStore(t)
+	first, next, err := s.QueryPosts(bg, Query{Sort: "created_at", Desc: true, Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Postingan yang lebih baru disimpan di antara dua halaman tidak menggeser halaman berikutnya.
+	if _, err := s.SaveRun("kopi", time.Unix(6000, 0), []split.Post{{Code: "G", CreatedAt: 700}}); err != nil {
+		t.Fatal(err)
+	}
+	second, _, err := s.QueryPosts(bg, Query{Sort: "created_at", Desc: true, Limit: 2, Cursor: next})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := append(codes(first), codes(second)...); !reflect.DeepEqual(got, []string{"F", "E", "D", "C"}) {
+		t.Errorf("pages = %v, want [F E D C]", got)
+	}
+}
+
+func TestQueryPostsErrors(t *testing.T) {
+	s := seedQueryStore(t)
+	_, next, err := s.QueryPosts(bg, Query{Sort: "likes", Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		q    Query
+		want error
+	}{
+		{"sort tidak dikenal", Query{Sort: "views"}, ErrBadQuery},
+		{"limit negatif", Query{Limit: -1}, ErrBadQuery},
+		{"hashtag tidak valid", Query{Hashtag: "../kopi"}, ErrBadQuery},
+		{"cursor rusak", Query{Cursor: "!!!"}, ErrBadCursor},
+		{"cursor bukan JSON", Query{Cursor: "YWJj"}, ErrBadCursor},
+		{"cursor dari sort lain", Query{Sort: "comments", Cursor: next}, ErrBadCursor},
+		{"cursor dari arah lain", Query{Sort: "likes", Desc: true, Cursor: next}, ErrBadCursor},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := s.QueryPosts(bg, tt.q); !errors.Is(err, tt.want) {
+				t.Errorf("QueryPosts() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryPostsLimitBounds(t *testing.T) {
+	s := openTestStore(t)
+	many := make([]split.Post, MaxQueryLimit+5)
+	for i := range many {
+		many[i] = split.Post{Code: fmt.Sprintf("P%04d", i), CreatedAt: int64(i)}
+	}
+	if _, err := s.SaveRun("kopi", time.Unix(1, 0), many); err != nil {
+		t.Fatal(err)
+	}
+	posts, next, err := s.QueryPosts(bg, Query{})
+	if err != nil || len(posts) != DefaultQueryLimit || next == "" {
+		t.Errorf("QueryPosts(zero limit) = %d posts, next %q, %v; want %d and a cursor", len(posts), next, err, DefaultQueryLimit)
+	}
+	posts, next, err = s.QueryPosts(bg, Query{Limit: MaxQueryLimit * 2})
+	if err != nil || len(posts) != MaxQueryLimit || next == "" {
+		t.Errorf("QueryPosts(huge limit) = %d posts, next %q, %v; want %d and a cursor", len(posts), next, err, MaxQueryLimit)
+	}
+}
+
+func codes(posts []StoredPost) []string {
+	out := make([]string, len(posts))
+	for i, p := range posts {
+		out[i] = p.Code
+	}
+	return out
+}
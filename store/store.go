@@ -0,0 +1,232 @@
+// Package store menyimpan postingan hasil scraping di database SQLite embedded,
+// sehingga riwayat tidak hilang setiap kali file extracted_posts_<hashtag>.json ditimpa.
+//
+// Postingan di-upsert dengan kunci shortcode (media code). Setiap postingan
+// menyimpan kapan pertama dan terakhir kali terlihat, serta hashtag apa saja
+// yang memunculkannya. Setiap kali Save dipanggil dihitung sebagai satu "run",
+// dan metrik (komentar, like, play) setiap postingan di run tersebut disimpan
+// sebagai snapshot tersendiri sehingga data terakumulasi antar scraping.
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	_ "modernc.org/sqlite" // Driver SQLite murni Go (image Docker dibangun dengan CGO_ENABLED=0)
+
+	"instagram-scraper/posts"
+	"instagram-scraper/split"
+	"instagram-scraper/tagname"
+)
+
+// schema dibuat saat Open jika tabelnya belum ada.
+const schema = `
+CREATE TABLE IF NOT EXISTS posts (
+	code           TEXT PRIMARY KEY,
+	owner_username TEXT NOT NULL,
+	text           TEXT NOT NULL,
+	post_url       TEXT NOT NULL,
+	created_at     INTEGER NOT NULL,
+	comments       INTEGER NOT NULL,
+	likes          INTEGER NOT NULL,
+	plays          INTEGER NOT NULL,
+	first_seen     INTEGER NOT NULL,
+	last_seen      INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS post_hashtags (
+	code       TEXT NOT NULL REFERENCES posts(code),
+	hashtag    TEXT NOT NULL,
+	first_seen INTEGER NOT NULL,
+	last_seen  INTEGER NOT NULL,
+	PRIMARY KEY (code, hashtag)
+);
+CREATE INDEX IF NOT EXISTS post_hashtags_hashtag ON post_hashtags(hashtag);
+CREATE TABLE IF NOT EXISTS runs (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	hashtag    TEXT NOT NULL,
+	scraped_at INTEGER NOT NULL,
+	posts      INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS metric_snapshots (
+	run_id      INTEGER NOT NULL REFERENCES runs(id),
+	code        TEXT NOT NULL REFERENCES posts(code),
+	captured_at INTEGER NOT NULL,
+	comments    INTEGER NOT NULL,
+	likes       INTEGER NOT NULL,
+	plays       INTEGER NOT NULL,
+	PRIMARY KEY (run_id, code)
+);
+CREATE INDEX IF NOT EXISTS metric_snapshots_code ON metric_snapshots(code, captured_at);
+`
+
+// migrations mengubah skema database lama. migrations[i] membawa database dari
+// PRAGMA user_version i ke i+1. Tambahkan entri baru di akhir; jangan ubah entri lama.
+var migrations = []string{
+	// 1: tipe media untuk filter media_type.
+	`ALTER TABLE posts ADD COLUMN media_type TEXT NOT NULL DEFAULT '';
+	CREATE INDEX IF NOT EXISTS posts_created_at ON posts(created_at);`,
+	// 2: hashtag disimpan dalam bentuk normal (tanpa '#', huruf kecil; lihat tagname).
+	// lower() SQLite hanya mengubah huruf ASCII, cukup untuk data lama yang belum dinormalkan.
+	// Baris yang bentrok dengan bentuk normalnya yang sudah ada dibuang.
+	`UPDATE OR IGNORE post_hashtags SET hashtag = lower(ltrim(hashtag, '#')) WHERE hashtag <> lower(ltrim(hashtag, '#'));
+	DELETE FROM post_hashtags WHERE hashtag <> lower(ltrim(hashtag, '#'));
+	UPDATE runs SET hashtag = lower(ltrim(hashtag, '#')) WHERE hashtag <> lower(ltrim(hashtag, '#'));`,
+}
+
+// Store adalah koneksi ke database SQLite. Aman dipakai dari banyak goroutine.
+type Store struct {
+	db *sql.DB
+}
+
+// Open membuka (atau membuat) database di path dan memastikan skemanya ada.
+// Folder induk path dibuat jika belum ada.
+func Open(path string) (*Store, error) {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("creating directory for %s: %w", path, err)
+		}
+	}
+	// WAL mengizinkan pembaca berjalan bersamaan dengan penulis; busy_timeout
+	// membuat penulis yang bersamaan menunggu alih-alih langsung gagal.
+	dsn := fmt.Sprintf("file:%s?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)&_pragma=foreign_keys(1)", path)
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("opening database %s: %w", path, err)
+	}
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("creating schema in %s: %w", path, err)
+	}
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrating schema in %s: %w", path, err)
+	}
+	log.Printf("Post store opened at '%s'", path)
+	return &Store{db: db}, nil
+}
+
+// migrate menjalankan migrasi yang belum diterapkan, masing-masing dalam transaksinya sendiri.
+func migrate(db *sql.DB) error {
+	var version int
+	if err := db.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		return err
+	}
+	for ; version < len(migrations); version++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(migrations[version]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: %w", version+1, err)
+		}
+		// PRAGMA tidak mendukung parameter, jadi versinya diformat langsung.
+		if _, err := tx.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, version+1)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: %w", version+1, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("migration %d: %w", version+1, err)
+		}
+		log.Printf("Applied post store migration %d", version+1)
+	}
+	return nil
+}
+
+// Close menutup koneksi database.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Save mengimplementasikan scraper.Sink: menyimpan postingan hasil ekstraksi
+// sebagai satu run baru untuk hashtag.
+func (s *Store) Save(hashtag string, result *posts.Result, extracted []split.Post) error {
+	_, err := s.SaveRun(hashtag, time.Now().UTC(), extracted)
+	return err
+}
+
+// SaveRun meng-upsert postingan, mencatat hashtag-nya, dan menyimpan snapshot
+// metrik untuk run baru dalam satu transaksi. Postingan tanpa shortcode dilewati
+// karena tidak bisa di-dedup. ID run yang baru dikembalikan. hashtag disimpan
+// dalam bentuk normalnya (lihat tagname.Normalize).
+func (s *Store) SaveRun(hashtag string, scrapedAt time.Time, extracted []split.Post) (int64, error) {
+	hashtag, err := tagname.Normalize(hashtag)
+	if err != nil {
+		return 0, err
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := scrapedAt.Unix()
+	res, err := tx.Exec(`INSERT INTO runs (hashtag, scraped_at, posts) VALUES (?, ?, ?)`, hashtag, now, len(extracted))
+	if err != nil {
+		return 0, fmt.Errorf("inserting run: %w", err)
+	}
+	runID, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("reading run id: %w", err)
+	}
+
+	upsertPost, err := tx.Prepare(`
+		INSERT INTO posts (code, owner_username, text, post_url, created_at, comments, likes, plays, media_type, first_seen, last_seen)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(code) DO UPDATE SET
+			owner_username = excluded.owner_username,
+			text           = excluded.text,
+			post_url       = excluded.post_url,
+			created_at     = excluded.created_at,
+			comments       = excluded.comments,
+			likes          = excluded.likes,
+			plays          = excluded.plays,
+			media_type     = excluded.media_type,
+			last_seen      = excluded.last_seen`)
+	if err != nil {
+		return 0, fmt.Errorf("preparing post upsert: %w", err)
+	}
+	defer upsertPost.Close()
+
+	upsertHashtag, err := tx.Prepare(`
+		INSERT INTO post_hashtags (code, hashtag, first_seen, last_seen) VALUES (?, ?, ?, ?)
+		ON CONFLICT(code, hashtag) DO UPDATE SET last_seen = excluded.last_seen`)
+	if err != nil {
+		return 0, fmt.Errorf("preparing hashtag upsert: %w", err)
+	}
+	defer upsertHashtag.Close()
+
+	insertSnapshot, err := tx.Prepare(`
+		INSERT OR REPLACE INTO metric_snapshots (run_id, code, captured_at, comments, likes, plays) VALUES (?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return 0, fmt.Errorf("preparing snapshot insert: %w", err)
+	}
+	defer insertSnapshot.Close()
+
+	saved := 0
+	for _, post := range extracted {
+		if post.Code == "" {
+			continue
+		}
+		if _, err := upsertPost.Exec(post.Code, post.OwnerUsername, post.Text, post.PostURL, post.CreatedAt, post.Comments, post.Likes, post.Plays, post.MediaType, now, now); err != nil {
+			return 0, fmt.Errorf("upserting post %s: %w", post.Code, err)
+		}
+		if _, err := upsertHashtag.Exec(post.Code, hashtag, now, now); err != nil {
+			return 0, fmt.Errorf("upserting hashtag of post %s: %w", post.Code, err)
+		}
+		if _, err := insertSnapshot.Exec(runID, post.Code, now, post.Comments, post.Likes, post.Plays); err != nil {
+			return 0, fmt.Errorf("inserting snapshot of post %s: %w", post.Code, err)
+		}
+		saved++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("committing run: %w", err)
+	}
+	log.Printf("Stored run %d for hashtag '%s': %d posts upserted.", runID, hashtag, saved)
+	return runID, nil
+}
@@ -0,0 +1,159 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"instagram-scraper/split"
+)
+
+// bg adalah context untuk query di pengujian.
+var bg = context.Background()
+
+// openTestStore membuka database baru di folder sementara.
+func openTestStore(t *testing.T) *Store {
+	t.Helper()
+	s, err := Open(filepath.Join(t.TempDir(), "posts.db"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestOpenCreatesParentDirectory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a", "b", "posts.db")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open(%s) error = %v", path, err)
+	}
+	defer s.Close()
+	if version := userVersion(t, s.db); version != len(migrations) {
+		t.Errorf("user_version = %d, want %d", version, len(migrations))
+	}
+}
+
+func TestMigrateFromVersionZero(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "posts.db")
+
+	// Database versi 0: skema awal tanpa media_type, dengan hashtag yang belum dinormalkan.
+	old, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, stmt := range []string{
+		schema,
+		`INSERT INTO posts (code, owner_username, text, post_url, created_at, comments, likes, plays, first_seen, last_seen)
+		 VALUES ('A1', 'budi', '#kopi', 'u', 100, 1, 2, 0, 10, 20), ('B2', 'sari', '#Kopi', 'u', 200, 3, 4, 0, 10, 10)`,
+		// A1 muncul sebagai "#Kopi" dan "kopi": setelah migrasi hanya satu baris "kopi" yang tersisa.
+		`INSERT INTO post_hashtags (code, hashtag, first_seen, last_seen)
+		 VALUES ('A1', '#Kopi', 10, 10), ('A1', 'kopi', 20, 20), ('B2', 'KOPI', 10, 10), ('B2', 'teh', 10, 10)`,
+		`INSERT INTO runs (hashtag, scraped_at, posts) VALUES ('#Kopi', 10, 2), ('kopi', 20, 1)`,
+	} {
+		if _, err := old.Exec(stmt); err != nil {
+			t.Fatalf("preparing version 0 database: %v", err)
+		}
+	}
+	if version := userVersion(t, old); version != 0 {
+		t.Fatalf("user_version = %d, want 0", version)
+	}
+	old.Close()
+
+	s, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer s.Close()
+	if version := userVersion(t, s.db); version != len(migrations) {
+		t.Errorf("user_version = %d, want %d", version, len(migrations))
+	}
+
+	// Migrasi 1: kolom media_type ada dengan default kosong.
+	var mediaType string
+	if err := s.db.QueryRow(`SELECT media_type FROM posts WHERE code = 'A1'`).Scan(&mediaType); err != nil || mediaType != "" {
+		t.Errorf("media_type = %q, %v; want empty default", mediaType, err)
+	}
+
+	// Migrasi 2: hashtag dinormalkan tanpa duplikat.
+	got := queryStrings(t, s.db, `SELECT code || ':' || hashtag FROM post_hashtags ORDER BY code, hashtag`)
+	if want := []string{"A1:kopi", "B2:kopi", "B2:teh"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("post_hashtags = %v, want %v", got, want)
+	}
+	got = queryStrings(t, s.db, `SELECT hashtag FROM runs ORDER BY id`)
+	if want := []string{"kopi", "kopi"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("runs.hashtag = %v, want %v", got, want)
+	}
+
+	// Membuka ulang database yang sudah termigrasi tidak menjalankan migrasi lagi.
+	s.Close()
+	again, err := Open(path)
+	if err != nil {
+		t.Fatalf("reopening migrated database: %v", err)
+	}
+	again.Close()
+}
+
+func TestSaveRunUpserts(t *testing.T) {
+	s := openTestStore(t)
+	first := time.Unix(1000, 0)
+	if _, err := s.SaveRun("#Kopi", first, []split.Post{
+		{Code: "A1", OwnerUsername: "budi", Comments: 1, Likes: 5, CreatedAt: 100},
+		{Code: "", OwnerUsername: "tanpa kode"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.SaveRun("teh", first.Add(time.Hour), []split.Post{
+		{Code: "A1", OwnerUsername: "budi", Comments: 4, Likes: 9, CreatedAt: 100},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.SaveRun("bukan/hashtag", first, nil); err == nil {
+		t.Error("SaveRun(invalid hashtag) error = nil, want error")
+	}
+
+	posts, _, err := s.QueryPosts(bg, Query{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(posts) != 1 {
+		t.Fatalf("stored %d posts, want 1 (posts without a code are skipped)", len(posts))
+	}
+	p := posts[0]
+	if p.Comments != 4 || p.Likes != 9 || p.FirstSeen != 1000 || p.LastSeen != 4600 {
+		t.Errorf("stored post = %+v, want latest metrics with first_seen 1000 and last_seen 4600", p)
+	}
+	if want := []string{"kopi", "teh"}; !reflect.DeepEqual(p.Hashtags, want) {
+		t.Errorf("hashtags = %v, want %v", p.Hashtags, want)
+	}
+}
+
+func userVersion(t *testing.T, db *sql.DB) int {
+	t.Helper()
+	var version int
+	if err := db.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		t.Fatal(err)
+	}
+	return version
+}
+
+func queryStrings(t *testing.T, db *sql.DB, query string) []string {
+	t.Helper()
+	rows, err := db.Query(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var out []string
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, s)
+	}
+	return out
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"instagram-scraper/split"
+	"instagram-scraper/store"
+)
+
+// storedPostsResponse adalah body JSON untuk GET /stored/posts.
+type storedPostsResponse struct {
+	Posts      []store.StoredPost `json:"posts"`
+	NextCursor string             `json:"next_cursor,omitempty"` // Kosong jika tidak ada halaman berikutnya
+}
+
+// getStoredPostsHandler menangani GET /stored/posts: membaca postingan dari
+// database tanpa scraping ulang Instagram.
+func getStoredPostsHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("Received GET request for /stored/posts from %s", r.RemoteAddr)
+
+	if postStore == nil {
+		writeError(w, http.StatusServiceUnavailable, "store_disabled", "Post store is disabled (DB_PATH is empty).")
+		return
+	}
+
+	q, err := parseStoredQuery(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_query", err.Error())
+		return
+	}
+
+	found, next, err := postStore.QueryPosts(r.Context(), q)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, storedPostsResponse{Posts: found, NextCursor: next})
+}
+
+// parseStoredQuery membaca filter, urutan, dan paginasi GET /stored/posts dari query parameter.
+func parseStoredQuery(r *http.Request) (store.Query, error) {
+	v := r.URL.Query()
+	q := store.Query{
+		Hashtag:       v.Get("hashtag"),
+		OwnerUsername: v.Get("owner_username"),
+		Caption:       v.Get("caption"),
+		Cursor:        v.Get("cursor"),
+		Sort:          v.Get("sort"),
+	}
+
+	switch mt := v.Get("media_type"); mt {
+	case "", split.MediaTypeImage, split.MediaTypeVideo:
+		q.MediaType = mt
+	default:
+		return q, fmt.Errorf("query parameter 'media_type' must be one of: %s, %s", split.MediaTypeImage, split.MediaTypeVideo)
+	}
+
+	switch v.Get("order") {
+	case "", "desc":
+		q.Desc = true
+	case "asc":
+	default:
+		return q, fmt.Errorf("query parameter 'order' must be one of: asc, desc")
+	}
+
+	for _, p := range []struct {
+		name string
+		dst  *int64
+	}{
+		{"created_after", &q.CreatedAfter},
+		{"created_before", &q.CreatedBefore},
+	} {
+		if s := v.Get(p.name); s != "" {
+			n, err := split.ParseLimit(s)
+			if err != nil {
+				return q, fmt.Errorf("query parameter '%s' must be a Unix timestamp", p.name)
+			}
+			*p.dst = n
+		}
+	}
+
+	for _, p := range []struct {
+		name string
+		dst  *int
+	}{
+		{"min_comments", &q.MinComments},
+		{"min_likes", &q.MinLikes},
+		{"limit", &q.Limit},
+	} {
+		if s := v.Get(p.name); s != "" {
+			n, err := strconv.Atoi(s)
+			if err != nil || n < 0 {
+				return q, fmt.Errorf("query parameter '%s' must be a non-negative integer", p.name)
+			}
+			*p.dst = n
+		}
+	}
+	return q, nil
+}
+
+// getPostHistoryHandler menangani GET /stored/posts/{code}/history: kurva
+// pertumbuhan komentar, like, dan play satu postingan antar scraping.
+func getPostHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	if postStore == nil {
+		writeError(w, http.StatusServiceUnavailable, "store_disabled", "Post store is disabled (DB_PATH is empty).")
+		return
+	}
+	history, err := postStore.PostHistory(r.Context(), mux.Vars(r)["code"])
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, history)
+}
+
+// getHashtagVelocityHandler menangani GET /stored/hashtags/{hashtag}/velocity:
+// total engagement setiap run hashtag dan kecepatan pertambahannya.
+// Query parameter 'since' (Unix timestamp) membatasi run yang dihitung.
+func getHashtagVelocityHandler(w http.ResponseWriter, r *http.Request) {
+	if postStore == nil {
+		writeError(w, http.StatusServiceUnavailable, "store_disabled", "Post store is disabled (DB_PATH is empty).")
+		return
+	}
+	var since int64
+	if s := r.URL.Query().Get("since"); s != "" {
+		n, err := split.ParseLimit(s)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "bad_query", "Query parameter 'since' must be a Unix timestamp.")
+			return
+		}
+		since = n
+	}
+	velocity, err := postStore.HashtagVelocity(r.Context(), mux.Vars(r)["hashtag"], since)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, velocity)
+}
+
+// writeStoreError memetakan error dari paket store ke status HTTP.
+func writeStoreError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		writeError(w, http.StatusNotFound, "not_found", err.Error())
+	case errors.Is(err, store.ErrBadCursor):
+		writeError(w, http.StatusBadRequest, "bad_cursor", err.Error())
+	case errors.Is(err, store.ErrBadQuery):
+		writeError(w, http.StatusBadRequest, "bad_query", err.Error())
+	default:
+		log.Printf("Error querying post store: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+
+	"instagram-scraper/scraper"
+	"instagram-scraper/split"
+)
+
+// streamSummary adalah data event "summary" terakhir di /posts/stream.
+type streamSummary struct {
+	Hashtag string `json:"hashtag"`
+	Posts   int    `json:"posts"`
+	Pages   int    `json:"pages"`
+}
+
+// streamPostsHandler adalah handler HTTP untuk endpoint /posts/stream.
+// Query parameter-nya sama dengan /posts, tetapi hasilnya dikirim sebagai
+// Server-Sent Events selama scraping masih berjalan:
+//
+//	event: post      -> satu split.Post, segera setelah halamannya di-decode
+//	event: progress  -> {"feed": "top", "page": 2, "posts": 37} setelah setiap halaman
+//	event: summary   -> {"hashtag": "...", "posts": N, "pages": P} saat selesai
+//	event: error     -> {"error": "...", "code": "..."} jika scraping gagal
+//
+// Jika klien disconnect, context request dibatalkan sehingga request ke Instagram ikut berhenti.
+func streamPostsHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("Received GET request for /posts/stream from %s", r.RemoteAddr)
+
+	hashtags, scrapeOpts, ok := parseScrapeQuery(w, r)
+	if !ok {
+		return
+	}
+	if len(hashtags) > 1 {
+		writeError(w, http.StatusBadRequest, "multiple_hashtags", "Streaming supports a single hashtag. Use GET /posts or POST /jobs for several hashtags.")
+		return
+	}
+	hashtag := hashtags[0]
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming_unsupported", "Streaming is not supported by this server.")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // Matikan buffering di reverse proxy seperti nginx
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	send := func(event string, data interface{}) {
+		if err := writeSSE(w, event, data); err != nil {
+			// Biasanya karena klien sudah disconnect; context akan menghentikan scraping.
+			log.Printf("Error writing '%s' event for hashtag '%s': %v", event, hashtag, err)
+			return
+		}
+		flusher.Flush()
+	}
+
+	pages := 0
+	extracted, err := scraper.Stream(r.Context(), hashtag, scrapeOpts,
+		func(post split.Post) { send("post", post) },
+		func(p scraper.Progress) {
+			pages++
+			send("progress", p)
+		},
+	)
+	if err != nil {
+		if r.Context().Err() != nil {
+			log.Printf("Client disconnected from stream for hashtag '%s': %v", hashtag, err)
+			return
+		}
+		log.Printf("Error streaming hashtag '%s': %v", hashtag, err)
+		_, code := scrapeErrorStatus(err)
+		send("error", errorResponse{Error: err.Error(), Code: code})
+		return
+	}
+
+	send("summary", streamSummary{Hashtag: hashtag, Posts: len(extracted), Pages: pages})
+	log.Printf("Successfully streamed %d posts for hashtag '%s'.", len(extracted), hashtag)
+}
+
+// writeSSE menulis satu event Server-Sent Events dengan data berformat JSON.
+func writeSSE(w http.ResponseWriter, event string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload)
+	return err
+}
+
+// streamNDJSON menangani /posts?format=ndjson: setiap postingan ditulis sebagai
+// satu baris JSON begitu halamannya di-decode. Status 200 baru dikirim saat baris
+// pertama ditulis, jadi kegagalan sebelum itu tetap menjadi respons error biasa.
+// Jika scraping gagal setelah sebagian postingan terkirim, baris terakhir berisi
+// {"error": "...", "code": "..."}.
+func streamNDJSON(w http.ResponseWriter, r *http.Request, hashtag string, scrapeOpts scraper.Options, cols []split.Column) {
+	w.Header().Set("Content-Type", formatNDJSON.ContentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": "posts_" + hashtag + ".ndjson"}))
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.Header().Add("Vary", "Accept")
+
+	nw := split.NewNDJSONWriter(w, cols)
+	var writeErr error
+	_, err := scraper.Stream(r.Context(), hashtag, scrapeOpts, func(post split.Post) {
+		if writeErr != nil {
+			return
+		}
+		if writeErr = nw.Write(post); writeErr != nil {
+			// Biasanya karena klien sudah disconnect; context akan menghentikan scraping.
+			log.Printf("Error writing NDJSON for hashtag '%s': %v", hashtag, writeErr)
+		}
+	}, nil)
+	if err != nil {
+		if r.Context().Err() != nil {
+			log.Printf("Client disconnected from NDJSON stream for hashtag '%s': %v", hashtag, err)
+			return
+		}
+		log.Printf("Error scraping hashtag '%s': %v", hashtag, err)
+		if nw.Count() == 0 {
+			w.Header().Del("Content-Disposition")
+			writeScrapeError(w, err)
+			return
+		}
+		_, code := scrapeErrorStatus(err)
+		if err := json.NewEncoder(w).Encode(errorResponse{Error: err.Error(), Code: code}); err != nil {
+			log.Printf("Error writing NDJSON error line: %v", err)
+		}
+		return
+	}
+	if nw.Count() == 0 {
+		w.WriteHeader(http.StatusOK) // Tidak ada postingan: body kosong adalah NDJSON yang valid
+	}
+	log.Printf("Successfully streamed %d posts as NDJSON for hashtag '%s'.", nw.Count(), hashtag)
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"instagram-scraper/fakeig"
+	"instagram-scraper/posts"
+	"instagram-scraper/split"
+)
+
+// sseEvent adalah satu event Server-Sent Events yang sudah dibaca.
+type sseEvent struct {
+	Name string
+	Data string
+}
+
+// readSSE membaca event dari r sampai EOF atau sampai stop mengembalikan true.
+func readSSE(t *testing.T, r io.Reader, stop func(sseEvent) bool) []sseEvent {
+	t.Helper()
+	var events []sseEvent
+	var cur sseEvent
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			cur.Name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			cur.Data = strings.TrimPrefix(line, "data: ")
+		case line == "":
+			events = append(events, cur)
+			if stop != nil && stop(cur) {
+				return events
+			}
+			cur = sseEvent{}
+		}
+	}
+	return events
+}
+
+// eventNames mengembalikan nama event secara berurutan.
+func eventNames(events []sseEvent) string {
+	names := make([]string, len(events))
+	for i, e := range events {
+		names[i] = e.Name
+	}
+	return strings.Join(names, ",")
+}
+
+// streamPosts memanggil streamPostsHandler untuk query.
+func streamPosts(query string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/posts/stream?"+query, nil)
+	rec := httptest.NewRecorder()
+	streamPostsHandler(rec, req)
+	return rec
+}
+
+func TestStreamPostsEvents(t *testing.T) {
+	srv := newFakeInstagram(t)
+	srv.AddPosts("kopi", split.FeedTop, 2, fakePosts(4)...)
+
+	rec := streamPosts("hashtag=kopi")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200; body: %s", rec.Code, rec.Body)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "text/event-stream" {
+		t.Errorf("Content-Type = %q, want text/event-stream", got)
+	}
+
+	events := readSSE(t, rec.Body, nil)
+	want := "post,post,progress,post,post,progress,summary"
+	if got := eventNames(events); got != want {
+		t.Fatalf("events = %s, want %s", got, want)
+	}
+
+	var post split.Post
+	if err := json.Unmarshal([]byte(events[0].Data), &post); err != nil {
+		t.Fatal(err)
+	}
+	if post.Code != "P1" {
+		t.Errorf("first post = %q, want P1", post.Code)
+	}
+	var progress struct {
+		Feed  string `json:"feed"`
+		Page  int    `json:"page"`
+		Posts int    `json:"posts"`
+	}
+	if err := json.Unmarshal([]byte(events[5].Data), &progress); err != nil {
+		t.Fatal(err)
+	}
+	if progress.Feed != "top" || progress.Page != 2 || progress.Posts != 4 {
+		t.Errorf("last progress = %+v, want top page 2 with 4 posts", progress)
+	}
+	var summary streamSummary
+	if err := json.Unmarshal([]byte(events[6].Data), &summary); err != nil {
+		t.Fatal(err)
+	}
+	if summary != (streamSummary{Hashtag: "kopi", Posts: 4, Pages: 2}) {
+		t.Errorf("summary = %+v", summary)
+	}
+}
+
+func TestStreamPostsUpstreamError(t *testing.T) {
+	srv := newFakeInstagram(t)
+	srv.AddPosts("kopi", split.FeedTop, 0, fakePosts(2)...)
+	srv.Fail(fakeig.FaultUnauthorized, 10)
+
+	rec := streamPosts("hashtag=kopi")
+	events := readSSE(t, rec.Body, nil)
+	if got := eventNames(events); got != "error" {
+		t.Fatalf("events = %s, want error", got)
+	}
+	var body errorResponse
+	if err := json.Unmarshal([]byte(events[0].Data), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Code != "unauthorized" {
+		t.Errorf("error code = %q, want unauthorized (%s)", body.Code, body.Error)
+	}
+}
+
+func TestStreamPostsClientDisconnectStopsFetching(t *testing.T) {
+	srv := newFakeInstagram(t)
+	srv.AddPosts("kopi", split.FeedTop, 1, fakePosts(20)...)
+	// Satu request per 100ms, agar masih ada halaman tersisa saat klien disconnect.
+	posts.SetRateLimit(posts.RateLimit{PerMinute: 600, Burst: 1}, posts.RateLimit{})
+
+	api := httptest.NewServer(http.HandlerFunc(streamPostsHandler))
+	defer api.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, api.URL+"/posts/stream?hashtag=kopi&max_pages=20", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	readSSE(t, resp.Body, func(e sseEvent) bool { return e.Name == "progress" })
+	cancel()
+
+	// Beri waktu handler untuk melihat pembatalan, lalu pastikan tidak ada request baru.
+	time.Sleep(300 * time.Millisecond)
+	after := len(srv.Requests())
+	time.Sleep(300 * time.Millisecond)
+	if n := len(srv.Requests()); n != after || n >= 20 {
+		t.Errorf("Instagram got %d requests (%d right after disconnect), want fetching to stop early", n, after)
+	}
+}
@@ -0,0 +1,74 @@
+// Package tagname menormalkan dan memvalidasi nama hashtag sebelum dipakai di
+// URL Instagram, nama file output, maupun kunci di database.
+//
+// Bentuk normal sebuah hashtag adalah tanpa '#' di depan, dalam Unicode NFC, dan
+// huruf kecil. Karakter yang diizinkan mengikuti Instagram: huruf dan angka dari
+// aksara apa pun (termasuk tanda diakritik gabungan seperti pada aksara Devanagari
+// atau Thai), underscore, serta zero-width joiner/non-joiner yang dibutuhkan
+// beberapa aksara. Karena '/', '.', '\' dan spasi tidak diizinkan, hashtag yang
+// valid selalu aman dipakai sebagai bagian dari path file.
+package tagname
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// MaxLength adalah panjang maksimum hashtag (dalam karakter, setelah dinormalkan).
+const MaxLength = 100
+
+// ErrInvalid dikembalikan jika hashtag kosong, terlalu panjang, atau berisi karakter yang tidak diizinkan.
+var ErrInvalid = errors.New("invalid hashtag")
+
+const (
+	zeroWidthNonJoiner = '\u200c'
+	zeroWidthJoiner    = '\u200d'
+)
+
+// Normalize mengembalikan bentuk normal hashtag: spasi di tepi dan satu '#' di
+// depan dibuang, lalu diubah ke NFC huruf kecil. Error-nya membungkus ErrInvalid.
+func Normalize(hashtag string) (string, error) {
+	s := strings.TrimPrefix(strings.TrimSpace(hashtag), "#")
+	// Huruf kecil sebuah karakter NFC belum tentu NFC, jadi dinormalkan lagi setelah ToLower.
+	s = norm.NFC.String(strings.ToLower(norm.NFC.String(s)))
+
+	if s == "" {
+		return "", fmt.Errorf("%w: %q is empty", ErrInvalid, hashtag)
+	}
+	if !utf8.ValidString(s) {
+		return "", fmt.Errorf("%w: %q is not valid UTF-8", ErrInvalid, hashtag)
+	}
+	if n := utf8.RuneCountInString(s); n > MaxLength {
+		return "", fmt.Errorf("%w: %q is %d characters long (max %d)", ErrInvalid, hashtag, n, MaxLength)
+	}
+	digitsOnly := true
+	for _, r := range s {
+		if !allowed(r) {
+			return "", fmt.Errorf("%w: %q contains %q (only letters, digits and '_' are allowed)", ErrInvalid, hashtag, r)
+		}
+		if !unicode.IsDigit(r) {
+			digitsOnly = false
+		}
+	}
+	// Instagram tidak menautkan hashtag yang hanya berisi angka.
+	if digitsOnly {
+		return "", fmt.Errorf("%w: %q must contain at least one letter or '_'", ErrInvalid, hashtag)
+	}
+	return s, nil
+}
+
+// allowed melaporkan apakah r boleh muncul di hashtag.
+func allowed(r rune) bool {
+	switch {
+	case r == '_', r == zeroWidthNonJoiner, r == zeroWidthJoiner:
+		return true
+	case unicode.IsLetter(r), unicode.IsMark(r), unicode.IsDigit(r):
+		return true
+	}
+	return false
+}
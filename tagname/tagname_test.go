@@ -0,0 +1,71 @@
+package tagname
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string // Kosong jika harus ErrInvalid
+	}{
+		{"huruf kecil", "kopi", "kopi"},
+		{"huruf besar dan #", "#KopiSusu", "kopisusu"},
+		{"spasi di tepi", "  #kopi \n", "kopi"},
+		{"hanya satu # dibuang", "##kopi", ""},
+		{"underscore dan angka", "Kopi_2024", "kopi_2024"},
+		// "é" sebagai e + combining acute (NFD) menjadi satu code point (NFC).
+		{"NFD menjadi NFC", "cafe\u0301", "caf\u00e9"},
+		{"NFC huruf besar", "CAF\u00c9", "caf\u00e9"},
+		{"thai", "กาแฟ", "กาแฟ"},
+		{"devanagari dengan tanda vokal", "कॉफ़ी", "कॉफ़ी"},
+		{"jepang", "コーヒー", "コーヒー"},
+		{"arab", "قهوة", "قهوة"},
+		{"zero-width joiner", "\u0915\u094d\u200d\u0937", "\u0915\u094d\u200d\u0937"},
+		{"angka non-latin dengan huruf", "كوب٣", "كوب٣"},
+		{"kosong", "", ""},
+		{"hanya #", "#", ""},
+		{"hanya angka", "2024", ""},
+		{"hanya angka arab", "٢٠٢٤", ""},
+		{"titik ganda", "..", ""},
+		{"path traversal", "../etc", ""},
+		{"garis miring", "kopi/susu", ""},
+		{"backslash", `kopi\susu`, ""},
+		{"spasi di tengah", "kopi susu", ""},
+		{"tanda hubung", "kopi-susu", ""},
+		{"emoji", "kopi☕", ""},
+		{"UTF-8 rusak", "kopi\xff", ""},
+		{"tepat MaxLength", strings.Repeat("a", MaxLength), strings.Repeat("a", MaxLength)},
+		{"lebih dari MaxLength", strings.Repeat("a", MaxLength+1), ""},
+		{"MaxLength dalam karakter, bukan byte", strings.Repeat("ก", MaxLength), strings.Repeat("ก", MaxLength)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Normalize(tt.in)
+			if tt.want == "" {
+				if !errors.Is(err, ErrInvalid) {
+					t.Errorf("Normalize(%q) = %q, %v; want ErrInvalid", tt.in, got, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("Normalize(%q) = %q, %v; want %q", tt.in, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeIsIdempotent(t *testing.T) {
+	for _, in := range []string{"#KopiSusu", "café", "กาแฟ", "İstanbul", "ΣΟΦΙΑ"} {
+		once, err := Normalize(in)
+		if err != nil {
+			t.Fatalf("Normalize(%q) error = %v", in, err)
+		}
+		if twice, err := Normalize(once); err != nil || twice != once {
+			t.Errorf("Normalize(Normalize(%q)) = %q, %v; want %q", in, twice, err, once)
+		}
+	}
+}